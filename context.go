@@ -16,6 +16,7 @@ package zipkin
 
 import (
 	"context"
+	"strings"
 
 	"github.com/openzipkin/zipkin-go/model"
 )
@@ -58,6 +59,31 @@ func BaggageFromContext(ctx context.Context) model.BaggageFields {
 	return nil
 }
 
+// LogFieldsFromContext returns the trace ID and span ID of the span found in
+// context, followed by the requested baggage fields if available, as
+// alternating key/value pairs. The result can be passed to structured loggers
+// for log correlation. Returns nil if no span is found in context.
+func LogFieldsFromContext(ctx context.Context, baggageKeys ...string) []interface{} {
+	span := SpanFromContext(ctx)
+	if span == nil {
+		return nil
+	}
+	sc := span.Context()
+	fields := []interface{}{
+		"traceId", sc.TraceID.String(),
+		"spanId", sc.ID.String(),
+	}
+	if sc.Baggage == nil {
+		return fields
+	}
+	for _, key := range baggageKeys {
+		if values := sc.Baggage.Get(key); len(values) > 0 {
+			fields = append(fields, key, strings.Join(values, ","))
+		}
+	}
+	return fields
+}
+
 type ctxKey struct{}
 
 var spanKey = ctxKey{}
@@ -16,7 +16,12 @@ package zipkin
 
 import (
 	"context"
+	"reflect"
 	"testing"
+
+	"github.com/openzipkin/zipkin-go/model"
+	"github.com/openzipkin/zipkin-go/propagation/baggage"
+	"github.com/openzipkin/zipkin-go/reporter"
 )
 
 func TestSpanOrNoopFromContext(t *testing.T) {
@@ -37,3 +42,30 @@ func TestSpanOrNoopFromContext(t *testing.T) {
 	}
 
 }
+
+func TestLogFieldsFromContext(t *testing.T) {
+	var (
+		ctx   = context.Background()
+		rep   = reporter.NewNoopReporter()
+		tr, _ = NewTracer(rep)
+		sc    = model.SpanContext{Baggage: baggage.New("tenant-id").New()}
+	)
+	defer rep.Close()
+
+	if have := LogFieldsFromContext(ctx); have != nil {
+		t.Errorf("Invalid response want nil, have %+v", have)
+	}
+
+	sc.Baggage.Set("tenant-id", "acme")
+	span := tr.StartSpan("test", Parent(sc))
+	ctx = NewContext(ctx, span)
+
+	want := []interface{}{
+		"traceId", span.Context().TraceID.String(),
+		"spanId", span.Context().ID.String(),
+		"tenant-id", "acme",
+	}
+	if have := LogFieldsFromContext(ctx, "tenant-id", "country"); !reflect.DeepEqual(want, have) {
+		t.Errorf("Invalid response want %+v, have %+v", want, have)
+	}
+}
@@ -27,3 +27,36 @@ type BaggageHandler interface {
 	// middlewares/grpc/server.go and middlewares/http/server.go
 	New() model.BaggageFields
 }
+
+// RemoteBaggageFields can be implemented by BaggageFields implementations
+// which use different names for fields on the wire than in process, or which
+// hold fields that should never leave the process.
+type RemoteBaggageFields interface {
+	// AddRemote adds the provided values found in a remote header or metadata
+	// key. If the key is not a known remote name, it will return false.
+	AddRemote(key string, values ...string) bool
+	// IterateRemote iterates over the fields to propagate using their remote
+	// names. Local only fields are skipped.
+	IterateRemote(f func(key string, values []string))
+}
+
+// ExtractBaggage adds the values found in an incoming header or metadata key
+// to the provided BaggageFields, honoring remote name mappings if supported by
+// the BaggageFields implementation.
+func ExtractBaggage(fields model.BaggageFields, key string, values ...string) bool {
+	if r, ok := fields.(RemoteBaggageFields); ok {
+		return r.AddRemote(key, values...)
+	}
+	return fields.Add(key, values...)
+}
+
+// InjectBaggage iterates over the fields to propagate to outgoing requests,
+// honoring remote name mappings and local only fields if supported by the
+// BaggageFields implementation.
+func InjectBaggage(fields model.BaggageFields, f func(key string, values []string)) {
+	if r, ok := fields.(RemoteBaggageFields); ok {
+		r.IterateRemote(f)
+		return
+	}
+	fields.Iterate(f)
+}
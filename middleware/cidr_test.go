@@ -0,0 +1,43 @@
+// Copyright 2022 The OpenZipkin Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package middleware_test
+
+import (
+	"net"
+	"testing"
+
+	"github.com/openzipkin/zipkin-go/middleware"
+)
+
+func TestParseCIDRs(t *testing.T) {
+	if _, err := middleware.ParseCIDRs("10.0.0.0/33"); err == nil {
+		t.Error("expected error for invalid CIDR range")
+	}
+
+	nets, err := middleware.ParseCIDRs("10.0.0.0/8", "2001:db8::/32")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for ip, want := range map[string]bool{
+		"10.1.2.3":    true,
+		"2001:db8::1": true,
+		"192.168.1.1": false,
+		"invalid":     false,
+	} {
+		if have := nets.Contains(net.ParseIP(ip)); want != have {
+			t.Errorf("unexpected result for %s, want %t, have %t", ip, want, have)
+		}
+	}
+}
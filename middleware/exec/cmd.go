@@ -0,0 +1,230 @@
+// Copyright 2022 The OpenZipkin Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exec
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"sync"
+
+	"github.com/openzipkin/zipkin-go"
+	"github.com/openzipkin/zipkin-go/model"
+	"github.com/openzipkin/zipkin-go/propagation/env"
+)
+
+// Common Tag keys
+const (
+	TagExecPath     = "exec.path"
+	TagExecExitCode = "exec.exit_code"
+	TagExecSignal   = "exec.signal"
+)
+
+// Cmd wraps exec.Cmd and records a client span covering the lifetime of the
+// child process. The span is started by Start and finished by Wait.
+type Cmd struct {
+	*exec.Cmd
+	tracer        *zipkin.Tracer
+	ctx           context.Context
+	name          string
+	injectOptions []env.InjectOption
+	defaultTags   map[string]string
+
+	mtx  sync.Mutex
+	span zipkin.Span
+}
+
+// CmdOption allows optional configuration of Cmd.
+type CmdOption func(*Cmd)
+
+// SpanName sets the name of the spans created for the command. By default the
+// base name of the executable is used.
+func SpanName(name string) CmdOption {
+	return func(c *Cmd) {
+		c.name = name
+	}
+}
+
+// InjectOptions sets the options used for injecting the trace context into
+// the environment of the child process. By default the B3 single header
+// format is used.
+func InjectOptions(options ...env.InjectOption) CmdOption {
+	return func(c *Cmd) {
+		c.injectOptions = options
+	}
+}
+
+// CmdTags adds default Tags to inject into command spans.
+func CmdTags(tags map[string]string) CmdOption {
+	return func(c *Cmd) {
+		c.defaultTags = tags
+	}
+}
+
+// Command returns an instrumented Cmd to execute the named program with the
+// given arguments. See exec.Command.
+func Command(tracer *zipkin.Tracer, name string, args []string, options ...CmdOption) *Cmd {
+	return Wrap(context.Background(), tracer, exec.Command(name, args...), options...)
+}
+
+// CommandContext returns an instrumented Cmd to execute the named program with
+// the given arguments. The span found in ctx is used as parent of the command
+// span. See exec.CommandContext.
+func CommandContext(ctx context.Context, tracer *zipkin.Tracer, name string, args []string, options ...CmdOption) *Cmd {
+	return Wrap(ctx, tracer, exec.CommandContext(ctx, name, args...), options...)
+}
+
+// Wrap returns an instrumented Cmd around the provided exec.Cmd. The span
+// found in ctx is used as parent of the command span.
+func Wrap(ctx context.Context, tracer *zipkin.Tracer, cmd *exec.Cmd, options ...CmdOption) *Cmd {
+	c := &Cmd{
+		Cmd:    cmd,
+		tracer: tracer,
+		ctx:    ctx,
+		name:   filepath.Base(cmd.Path),
+	}
+	for _, option := range options {
+		option(c)
+	}
+	return c
+}
+
+// Start starts the command span, injects the trace context into the
+// environment of the child process and starts the child process.
+// See exec.Cmd.Start.
+func (c *Cmd) Start() error {
+	remoteEndpoint := &model.Endpoint{ServiceName: filepath.Base(c.Path)}
+	span, _ := c.tracer.StartSpanFromContext(
+		c.ctx, c.name, zipkin.Kind(model.Client), zipkin.RemoteEndpoint(remoteEndpoint),
+	)
+
+	_ = env.InjectCmd(c.Cmd, c.injectOptions...)(span.Context())
+
+	if !zipkin.IsNoop(span) {
+		for k, v := range c.defaultTags {
+			span.Tag(k, v)
+		}
+		span.Tag(TagExecPath, c.Path)
+	}
+
+	if err := c.Cmd.Start(); err != nil {
+		zipkin.TagError.Set(span, err.Error())
+		span.Finish()
+		return err
+	}
+
+	c.mtx.Lock()
+	c.span = span
+	c.mtx.Unlock()
+
+	return nil
+}
+
+// Wait waits for the command to exit and finishes the command span, tagging
+// the exit code and signal if the child process was terminated by one.
+// See exec.Cmd.Wait.
+func (c *Cmd) Wait() error {
+	err := c.Cmd.Wait()
+
+	c.mtx.Lock()
+	span := c.span
+	c.span = nil
+	c.mtx.Unlock()
+
+	if span == nil {
+		return err
+	}
+
+	if ps := c.ProcessState; ps != nil {
+		span.Tag(TagExecExitCode, strconv.Itoa(ps.ExitCode()))
+		if sig, ok := exitSignal(ps); ok {
+			span.Tag(TagExecSignal, sig)
+		}
+	}
+	if err != nil {
+		zipkin.TagError.Set(span, err.Error())
+	}
+	span.Finish()
+
+	return err
+}
+
+// Run starts the command and waits for it to complete. See exec.Cmd.Run.
+func (c *Cmd) Run() error {
+	if err := c.Start(); err != nil {
+		return err
+	}
+	return c.Wait()
+}
+
+// Output runs the command and returns its standard output. If Stderr is nil,
+// up to the first 32 KiB of standard error are captured into the Stderr field
+// of a returned *exec.ExitError. See exec.Cmd.Output.
+func (c *Cmd) Output() ([]byte, error) {
+	if c.Stdout != nil {
+		return nil, errors.New("exec: Stdout already set")
+	}
+	var stdout bytes.Buffer
+	c.Stdout = &stdout
+
+	var stderr *limitedBuffer
+	if c.Stderr == nil {
+		stderr = &limitedBuffer{max: maxStderrCapture}
+		c.Stderr = stderr
+	}
+
+	err := c.Run()
+	if ee, ok := err.(*exec.ExitError); ok && stderr != nil {
+		ee.Stderr = stderr.Bytes()
+	}
+	return stdout.Bytes(), err
+}
+
+// CombinedOutput runs the command and returns its combined standard output
+// and standard error. See exec.Cmd.CombinedOutput.
+func (c *Cmd) CombinedOutput() ([]byte, error) {
+	if c.Stdout != nil {
+		return nil, errors.New("exec: Stdout already set")
+	}
+	if c.Stderr != nil {
+		return nil, errors.New("exec: Stderr already set")
+	}
+	var b bytes.Buffer
+	c.Stdout = &b
+	c.Stderr = &b
+	err := c.Run()
+	return b.Bytes(), err
+}
+
+// maxStderrCapture caps the standard error captured by Output.
+const maxStderrCapture = 32 << 10
+
+// limitedBuffer holds up to max bytes, silently discarding the remainder.
+type limitedBuffer struct {
+	bytes.Buffer
+	max int
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	n := len(p)
+	if room := b.max - b.Len(); room < len(p) {
+		p = p[:room]
+	}
+	_, _ = b.Buffer.Write(p)
+	return n, nil
+}
@@ -0,0 +1,115 @@
+// Copyright 2022 The OpenZipkin Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exec_test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/openzipkin/zipkin-go"
+	zipkinexec "github.com/openzipkin/zipkin-go/middleware/exec"
+	"github.com/openzipkin/zipkin-go/model"
+	"github.com/openzipkin/zipkin-go/propagation/env"
+	"github.com/openzipkin/zipkin-go/reporter/recorder"
+)
+
+const helperEnv = "ZIPKIN_EXEC_HELPER"
+
+// TestMain allows the test binary to act as child process.
+func TestMain(m *testing.M) {
+	if os.Getenv(helperEnv) == "1" {
+		spanContext, err := env.Extract()()
+		if err != nil || spanContext == nil {
+			os.Exit(2)
+		}
+		fmt.Print(spanContext.TraceID.String())
+		fmt.Fprint(os.Stderr, "helper failed")
+		os.Exit(3)
+	}
+	os.Exit(m.Run())
+}
+
+func TestCommand(t *testing.T) {
+	var (
+		rec    = recorder.NewReporter()
+		tr, _  = zipkin.NewTracer(rec)
+		parent = tr.StartSpan("parent")
+		ctx    = zipkin.NewContext(context.Background(), parent)
+	)
+	defer rec.Close()
+
+	cmd := zipkinexec.CommandContext(
+		ctx, tr, os.Args[0], []string{"-test.run=^$"}, zipkinexec.SpanName("helper"),
+	)
+	cmd.Env = append(os.Environ(), helperEnv+"=1", env.B3+"=stale")
+
+	out, err := cmd.Output()
+	exitErr, ok := err.(*exec.ExitError)
+	if !ok {
+		t.Fatalf("expected exit error, have %v", err)
+	}
+	if want, have := "helper failed", string(exitErr.Stderr); want != have {
+		t.Errorf("captured stderr want %s, have %s", want, have)
+	}
+
+	if want, have := parent.Context().TraceID.String(), strings.TrimSpace(string(out)); want != have {
+		t.Errorf("child trace ID want %s, have %s", want, have)
+	}
+
+	spans := rec.Flush()
+	if want, have := 1, len(spans); want != have {
+		t.Fatalf("span count want %d, have %d", want, have)
+	}
+	span := spans[0]
+	if want, have := "helper", span.Name; want != have {
+		t.Errorf("Name want %s, have %s", want, have)
+	}
+	if want, have := model.Client, span.Kind; want != have {
+		t.Errorf("Kind want %s, have %s", want, have)
+	}
+	if want, have := parent.Context().ID, *span.ParentID; want != have {
+		t.Errorf("ParentID want %s, have %s", want, have)
+	}
+	if want, have := "3", span.Tags[zipkinexec.TagExecExitCode]; want != have {
+		t.Errorf("exit code tag want %s, have %s", want, have)
+	}
+	if want, have := "exit status 3", span.Tags[string(zipkin.TagError)]; want != have {
+		t.Errorf("error tag want %s, have %s", want, have)
+	}
+}
+
+func TestCommandStartError(t *testing.T) {
+	var (
+		rec   = recorder.NewReporter()
+		tr, _ = zipkin.NewTracer(rec)
+	)
+	defer rec.Close()
+
+	if err := zipkinexec.Command(tr, "/non/existing/binary", nil).Run(); err == nil {
+		t.Fatalf("expected start error")
+	}
+
+	spans := rec.Flush()
+	if want, have := 1, len(spans); want != have {
+		t.Fatalf("span count want %d, have %d", want, have)
+	}
+	if _, found := spans[0].Tags[string(zipkin.TagError)]; !found {
+		t.Errorf("expected error tag")
+	}
+}
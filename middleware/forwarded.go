@@ -0,0 +1,151 @@
+// Copyright 2022 The OpenZipkin Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package middleware
+
+import (
+	"net"
+	"strings"
+)
+
+// TagProxyAddress holds the address of the proxy which forwarded the request
+// if the remote endpoint was resolved from forwarding headers.
+const TagProxyAddress = "proxy.address"
+
+// ClientAddressResolver resolves the address of the originating client from
+// the address of the remote peer and the request headers, as returned by the
+// header lookup function. It returns nil if the address can't be resolved.
+type ClientAddressResolver func(peer net.IP, header func(key string) []string) net.IP
+
+// TrustedProxies selects the client address from the chain of addresses a
+// request passed through, ordered from the client towards the remote peer.
+type TrustedProxies func(chain []net.IP) net.IP
+
+// TrustProxyHops returns TrustedProxies which trusts the provided number of
+// proxies in front of the service, the remote peer included.
+func TrustProxyHops(hops int) TrustedProxies {
+	return func(chain []net.IP) net.IP {
+		idx := len(chain) - 1 - hops
+		if idx < 0 {
+			idx = 0
+		}
+		return chain[idx]
+	}
+}
+
+// TrustProxyCIDRs returns TrustedProxies which trusts proxies with an address
+// within the provided CIDR ranges. The client address is the right-most
+// address in the chain which is not a trusted proxy.
+func TrustProxyCIDRs(cidrs ...string) (TrustedProxies, error) {
+	nets, err := ParseCIDRs(cidrs...)
+	if err != nil {
+		return nil, err
+	}
+	return func(chain []net.IP) net.IP {
+		for i := len(chain) - 1; i > 0; i-- {
+			if !nets.Contains(chain[i]) {
+				return chain[i]
+			}
+		}
+		return chain[0]
+	}, nil
+}
+
+// ForwardedResolver returns a ClientAddressResolver using the "for"
+// parameters of the RFC 7239 Forwarded header.
+func ForwardedResolver(trust TrustedProxies) ClientAddressResolver {
+	return func(peer net.IP, header func(string) []string) net.IP {
+		var addrs []string
+		for _, value := range header("Forwarded") {
+			for _, element := range strings.Split(value, ",") {
+				for _, pair := range strings.Split(element, ";") {
+					kv := strings.SplitN(strings.TrimSpace(pair), "=", 2)
+					if len(kv) == 2 && strings.EqualFold(kv[0], "for") {
+						addrs = append(addrs, kv[1])
+					}
+				}
+			}
+		}
+		return resolve(trust, peer, addrs)
+	}
+}
+
+// XForwardedForResolver returns a ClientAddressResolver using the
+// X-Forwarded-For header.
+func XForwardedForResolver(trust TrustedProxies) ClientAddressResolver {
+	return func(peer net.IP, header func(string) []string) net.IP {
+		var addrs []string
+		for _, value := range header("X-Forwarded-For") {
+			addrs = append(addrs, strings.Split(value, ",")...)
+		}
+		return resolve(trust, peer, addrs)
+	}
+}
+
+// XRealIPResolver returns a ClientAddressResolver using the X-Real-IP header.
+func XRealIPResolver(trust TrustedProxies) ClientAddressResolver {
+	return func(peer net.IP, header func(string) []string) net.IP {
+		values := header("X-Real-IP")
+		if len(values) == 0 {
+			return nil
+		}
+		return resolve(trust, peer, values[len(values)-1:])
+	}
+}
+
+// FirstResolved returns a ClientAddressResolver which returns the address of
+// the first resolver able to resolve the client address.
+func FirstResolved(resolvers ...ClientAddressResolver) ClientAddressResolver {
+	return func(peer net.IP, header func(string) []string) net.IP {
+		for _, resolver := range resolvers {
+			if ip := resolver(peer, header); ip != nil {
+				return ip
+			}
+		}
+		return nil
+	}
+}
+
+// resolve parses the forwarded addresses and lets trust select the client
+// address from the chain ending with the remote peer. If any of the
+// addresses can't be parsed, e.g. obfuscated or "unknown" RFC 7239 nodes,
+// the client address is not resolved.
+func resolve(trust TrustedProxies, peer net.IP, addrs []string) net.IP {
+	if len(addrs) == 0 || peer == nil {
+		return nil
+	}
+	chain := make([]net.IP, 0, len(addrs)+1)
+	for _, addr := range addrs {
+		ip := parseNode(addr)
+		if ip == nil {
+			return nil
+		}
+		chain = append(chain, ip)
+	}
+	ip := trust(append(chain, peer))
+	if ip.Equal(peer) {
+		return nil
+	}
+	return ip
+}
+
+// parseNode parses an address which can be quoted and hold a port, e.g.
+// "[2001:db8::17]:4711" or 192.0.2.60:8080.
+func parseNode(node string) net.IP {
+	node = strings.Trim(strings.TrimSpace(node), `"`)
+	if host, _, err := net.SplitHostPort(node); err == nil {
+		node = host
+	}
+	return net.ParseIP(strings.Trim(node, "[]"))
+}
@@ -0,0 +1,124 @@
+// Copyright 2022 The OpenZipkin Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package middleware_test
+
+import (
+	"net"
+	"net/http"
+	"testing"
+
+	"github.com/openzipkin/zipkin-go/middleware"
+)
+
+func TestClientAddressResolvers(t *testing.T) {
+	trustCIDRs, err := middleware.TrustProxyCIDRs("10.0.0.0/8")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	testCases := []struct {
+		name     string
+		resolver middleware.ClientAddressResolver
+		peer     string
+		headers  map[string]string
+		want     string
+	}{
+		{
+			name:     "xff hops",
+			resolver: middleware.XForwardedForResolver(middleware.TrustProxyHops(1)),
+			peer:     "10.0.0.1",
+			headers:  map[string]string{"X-Forwarded-For": "1.2.3.4, 5.6.7.8"},
+			want:     "5.6.7.8",
+		},
+		{
+			name:     "xff hops beyond chain",
+			resolver: middleware.XForwardedForResolver(middleware.TrustProxyHops(5)),
+			peer:     "10.0.0.1",
+			headers:  map[string]string{"X-Forwarded-For": "1.2.3.4, 5.6.7.8"},
+			want:     "1.2.3.4",
+		},
+		{
+			name:     "xff cidrs",
+			resolver: middleware.XForwardedForResolver(trustCIDRs),
+			peer:     "10.0.0.1",
+			headers:  map[string]string{"X-Forwarded-For": "9.9.9.9, 1.2.3.4, 10.0.0.2"},
+			want:     "1.2.3.4",
+		},
+		{
+			name:     "xff untrusted peer",
+			resolver: middleware.XForwardedForResolver(trustCIDRs),
+			peer:     "8.8.8.8",
+			headers:  map[string]string{"X-Forwarded-For": "1.2.3.4"},
+		},
+		{
+			name:     "forwarded",
+			resolver: middleware.ForwardedResolver(middleware.TrustProxyHops(1)),
+			peer:     "10.0.0.1",
+			headers:  map[string]string{"Forwarded": `for=192.0.2.43, For="[2001:db8:cafe::17]:4711";proto=https`},
+			want:     "2001:db8:cafe::17",
+		},
+		{
+			name:     "forwarded obfuscated",
+			resolver: middleware.ForwardedResolver(middleware.TrustProxyHops(1)),
+			peer:     "10.0.0.1",
+			headers:  map[string]string{"Forwarded": "for=_hidden"},
+		},
+		{
+			name:     "x-real-ip",
+			resolver: middleware.XRealIPResolver(trustCIDRs),
+			peer:     "10.0.0.1",
+			headers:  map[string]string{"X-Real-IP": "1.2.3.4"},
+			want:     "1.2.3.4",
+		},
+		{
+			name: "first resolved",
+			resolver: middleware.FirstResolved(
+				middleware.ForwardedResolver(trustCIDRs),
+				middleware.XRealIPResolver(trustCIDRs),
+			),
+			peer:    "10.0.0.1",
+			headers: map[string]string{"X-Real-IP": "1.2.3.4"},
+			want:    "1.2.3.4",
+		},
+		{
+			name:     "no header",
+			resolver: middleware.XForwardedForResolver(middleware.TrustProxyHops(1)),
+			peer:     "10.0.0.1",
+		},
+	}
+
+	for _, tc := range testCases {
+		header := http.Header{}
+		for k, v := range tc.headers {
+			header.Set(k, v)
+		}
+		ip := tc.resolver(net.ParseIP(tc.peer), header.Values)
+		if tc.want == "" {
+			if ip != nil {
+				t.Errorf("%s: expected no address, have %s", tc.name, ip)
+			}
+			continue
+		}
+		if want, have := tc.want, ip.String(); want != have {
+			t.Errorf("%s: want %s, have %s", tc.name, want, have)
+		}
+	}
+}
+
+func TestTrustProxyCIDRsInvalid(t *testing.T) {
+	if _, err := middleware.TrustProxyCIDRs("10.0.0.0"); err == nil {
+		t.Error("expected error for invalid CIDR")
+	}
+}
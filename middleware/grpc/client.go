@@ -21,6 +21,7 @@ import (
 	"google.golang.org/grpc/stats"
 
 	"github.com/openzipkin/zipkin-go"
+	"github.com/openzipkin/zipkin-go/middleware"
 	"github.com/openzipkin/zipkin-go/model"
 	"github.com/openzipkin/zipkin-go/propagation/b3"
 )
@@ -28,6 +29,8 @@ import (
 type clientHandler struct {
 	tracer            *zipkin.Tracer
 	remoteServiceName string
+	rpcHandlers       []RPCHandler
+	messageStats      bool
 }
 
 // A ClientOption can be passed to NewClientHandler to customize the returned handler.
@@ -41,6 +44,23 @@ func WithRemoteServiceName(name string) ClientOption {
 	}
 }
 
+// WithClientRPCHandler allows one to add a RPCHandler which is called for all
+// RPC stats of recorded client spans, before the span is finished.
+func WithClientRPCHandler(handler RPCHandler) ClientOption {
+	return func(c *clientHandler) {
+		c.rpcHandlers = append(c.rpcHandlers, handler)
+	}
+}
+
+// WithClientMessageStats allows one to record message level stats on client
+// spans: request and response sizes, compression, message counts for
+// streaming RPCs and per-message annotations for streaming RPCs.
+func WithClientMessageStats(enabled bool) ClientOption {
+	return func(c *clientHandler) {
+		c.messageStats = enabled
+	}
+}
+
 // NewClientHandler returns a stats.Handler which can be used with grpc.WithStatsHandler to add
 // tracing to a gRPC client. The gRPC method name is used as the span name and by default the only
 // tags are the gRPC status code if the call fails.
@@ -67,7 +87,7 @@ func (c *clientHandler) TagConn(ctx context.Context, _ *stats.ConnTagInfo) conte
 
 // HandleRPC implements per-RPC tracing and stats instrumentation.
 func (c *clientHandler) HandleRPC(ctx context.Context, rs stats.RPCStats) {
-	handleRPC(ctx, rs)
+	handleRPC(ctx, rs, c.rpcHandlers)
 }
 
 // TagRPC implements per-RPC context management.
@@ -89,11 +109,14 @@ func (c *clientHandler) TagRPC(ctx context.Context, rti *stats.RPCTagInfo) conte
 
 	// inject baggage fields from span context into the outgoing gRPC request metadata
 	if span.Context().Baggage != nil {
-		span.Context().Baggage.Iterate(func(key string, values []string) {
+		middleware.InjectBaggage(span.Context().Baggage, func(key string, values []string) {
 			md.Set(key, values...)
 		})
 	}
 
 	ctx = metadata.NewOutgoingContext(ctx, md)
+	if c.messageStats {
+		ctx = withRPCState(ctx)
+	}
 	return ctx
 }
@@ -0,0 +1,58 @@
+// Copyright 2022 The OpenZipkin Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grpc_test
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/stats"
+
+	"github.com/openzipkin/zipkin-go"
+	"github.com/openzipkin/zipkin-go/middleware"
+	zipkingrpc "github.com/openzipkin/zipkin-go/middleware/grpc"
+	"github.com/openzipkin/zipkin-go/reporter/recorder"
+)
+
+func TestGRPCServerRemoteEndpointResolver(t *testing.T) {
+	var (
+		rec       = recorder.NewReporter()
+		tracer, _ = zipkin.NewTracer(rec)
+		handler   = zipkingrpc.NewServerHandler(tracer, zipkingrpc.ServerRemoteEndpointResolver(
+			middleware.XForwardedForResolver(middleware.TrustProxyHops(1)),
+		))
+		proxyAddr = &net.TCPAddr{IP: net.ParseIP("10.0.0.1"), Port: 1234}
+	)
+	defer rec.Close()
+
+	ctx := peer.NewContext(context.Background(), &peer.Peer{Addr: proxyAddr})
+	ctx = metadata.NewIncomingContext(ctx, metadata.Pairs("x-forwarded-for", "1.2.3.4"))
+	ctx = handler.TagRPC(ctx, &stats.RPCTagInfo{FullMethodName: "/test.Service/Method"})
+	handler.HandleRPC(ctx, &stats.End{})
+
+	spans := rec.Flush()
+	if want, have := 1, len(spans); want != have {
+		t.Fatalf("unexpected number of spans, want %d, have %d", want, have)
+	}
+	if ep := spans[0].RemoteEndpoint; ep == nil || ep.IPv4.String() != "1.2.3.4" {
+		t.Errorf("unexpected remote endpoint: %+v", ep)
+	}
+	if want, have := proxyAddr.String(), spans[0].Tags[middleware.TagProxyAddress]; want != have {
+		t.Errorf("unexpected proxy address tag, want %s, have %s", want, have)
+	}
+}
@@ -0,0 +1,138 @@
+// Copyright 2022 The OpenZipkin Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grpc
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/stats"
+
+	"github.com/openzipkin/zipkin-go"
+)
+
+// Message level tag keys
+const (
+	TagGRPCCompression            = "grpc.compression"
+	TagGRPCRequestSize            = "grpc.request.size"
+	TagGRPCRequestCompressedSize  = "grpc.request.compressed_size"
+	TagGRPCRequestMessages        = "grpc.request.messages"
+	TagGRPCResponseSize           = "grpc.response.size"
+	TagGRPCResponseCompressedSize = "grpc.response.compressed_size"
+	TagGRPCResponseMessages       = "grpc.response.messages"
+)
+
+// MaxMessageAnnotations caps the number of per-message annotations recorded
+// for streaming RPCs.
+const MaxMessageAnnotations = 128
+
+// rpcState accumulates the message level stats of an RPC.
+type rpcState struct {
+	mtx         sync.Mutex
+	streaming   bool
+	annotations int
+	request     messageStats
+	response    messageStats
+}
+
+type messageStats struct {
+	messages       int
+	size           int
+	compressedSize int
+}
+
+func (m *messageStats) add(length, compressedLength int) {
+	m.messages++
+	m.size += length
+	m.compressedSize += compressedLength
+}
+
+func (m messageStats) tag(sp zipkin.Span, sizeKey, compressedKey, messagesKey string, streaming bool) {
+	if m.messages == 0 {
+		return
+	}
+	sp.Tag(sizeKey, strconv.Itoa(m.size))
+	if m.compressedSize != m.size {
+		sp.Tag(compressedKey, strconv.Itoa(m.compressedSize))
+	}
+	if streaming {
+		sp.Tag(messagesKey, strconv.Itoa(m.messages))
+	}
+}
+
+type rpcStateKey struct{}
+
+func withRPCState(ctx context.Context) context.Context {
+	return context.WithValue(ctx, rpcStateKey{}, &rpcState{})
+}
+
+func rpcStateFromContext(ctx context.Context) *rpcState {
+	if s, ok := ctx.Value(rpcStateKey{}).(*rpcState); ok {
+		return s
+	}
+	return nil
+}
+
+// handle records the message level stats of the RPC.
+func (s *rpcState) handle(span zipkin.Span, rs stats.RPCStats) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	switch rs := rs.(type) {
+	case *stats.Begin:
+		s.streaming = rs.IsClientStream || rs.IsServerStream
+	case *stats.InHeader:
+		if rs.Compression != "" {
+			span.Tag(TagGRPCCompression, rs.Compression)
+		}
+	case *stats.OutHeader:
+		if rs.Compression != "" {
+			span.Tag(TagGRPCCompression, rs.Compression)
+		}
+	case *stats.InPayload:
+		// incoming messages are responses on the client and requests on the
+		// server.
+		if rs.Client {
+			s.response.add(rs.Length, rs.CompressedLength)
+		} else {
+			s.request.add(rs.Length, rs.CompressedLength)
+		}
+		s.annotate(span, rs.RecvTime, "message received")
+	case *stats.OutPayload:
+		if rs.Client {
+			s.request.add(rs.Length, rs.CompressedLength)
+		} else {
+			s.response.add(rs.Length, rs.CompressedLength)
+		}
+		s.annotate(span, rs.SentTime, "message sent")
+	case *stats.OutTrailer:
+		s.annotate(span, time.Now(), "trailers sent")
+	case *stats.End:
+		s.request.tag(span, TagGRPCRequestSize, TagGRPCRequestCompressedSize, TagGRPCRequestMessages, s.streaming)
+		s.response.tag(span, TagGRPCResponseSize, TagGRPCResponseCompressedSize, TagGRPCResponseMessages, s.streaming)
+	}
+}
+
+// annotate adds a message annotation for streaming RPCs. It must be called
+// with the lock held.
+func (s *rpcState) annotate(span zipkin.Span, t time.Time, value string) {
+	if !s.streaming || s.annotations >= MaxMessageAnnotations {
+		return
+	}
+	s.annotations++
+	span.Annotate(t, value)
+}
@@ -0,0 +1,123 @@
+// Copyright 2022 The OpenZipkin Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grpc_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/stats"
+
+	"github.com/openzipkin/zipkin-go"
+	zipkingrpc "github.com/openzipkin/zipkin-go/middleware/grpc"
+	"github.com/openzipkin/zipkin-go/reporter/recorder"
+)
+
+func TestGRPCServerMessageStats(t *testing.T) {
+	var (
+		rec       = recorder.NewReporter()
+		tracer, _ = zipkin.NewTracer(rec)
+		handled   []stats.RPCStats
+		handler   = zipkingrpc.NewServerHandler(
+			tracer,
+			zipkingrpc.WithServerMessageStats(true),
+			zipkingrpc.WithServerRPCHandler(func(span zipkin.Span, rs stats.RPCStats) {
+				handled = append(handled, rs)
+			}),
+		)
+		now = time.Now()
+	)
+	defer rec.Close()
+
+	ctx := handler.TagRPC(context.Background(), &stats.RPCTagInfo{FullMethodName: "/test.Service/Stream"})
+	for _, rs := range []stats.RPCStats{
+		&stats.Begin{IsServerStream: true},
+		&stats.InHeader{Compression: "gzip"},
+		&stats.InPayload{Length: 10, CompressedLength: 8, RecvTime: now},
+		&stats.OutPayload{Length: 20, CompressedLength: 20, SentTime: now},
+		&stats.OutPayload{Length: 30, CompressedLength: 30, SentTime: now},
+		&stats.OutTrailer{},
+		&stats.End{},
+	} {
+		handler.HandleRPC(ctx, rs)
+	}
+
+	if want, have := 7, len(handled); want != have {
+		t.Errorf("unexpected number of RPCHandler calls, want %d, have %d", want, have)
+	}
+
+	spans := rec.Flush()
+	if want, have := 1, len(spans); want != have {
+		t.Fatalf("unexpected number of spans, want %d, have %d", want, have)
+	}
+	for key, want := range map[string]string{
+		zipkingrpc.TagGRPCCompression:           "gzip",
+		zipkingrpc.TagGRPCRequestSize:           "10",
+		zipkingrpc.TagGRPCRequestCompressedSize: "8",
+		zipkingrpc.TagGRPCRequestMessages:       "1",
+		zipkingrpc.TagGRPCResponseSize:          "50",
+		zipkingrpc.TagGRPCResponseMessages:      "2",
+	} {
+		if have := spans[0].Tags[key]; want != have {
+			t.Errorf("unexpected %s tag, want %s, have %s", key, want, have)
+		}
+	}
+	if _, ok := spans[0].Tags[zipkingrpc.TagGRPCResponseCompressedSize]; ok {
+		t.Error("unexpected compressed size tag for uncompressed response")
+	}
+	if want, have := 4, len(spans[0].Annotations); want != have {
+		t.Errorf("unexpected number of annotations, want %d, have %d: %+v", want, have, spans[0].Annotations)
+	}
+}
+
+func TestGRPCClientMessageStatsUnary(t *testing.T) {
+	var (
+		rec       = recorder.NewReporter()
+		tracer, _ = zipkin.NewTracer(rec)
+		handler   = zipkingrpc.NewClientHandler(
+			tracer,
+			zipkingrpc.WithClientMessageStats(true),
+		)
+	)
+	defer rec.Close()
+
+	ctx := handler.TagRPC(context.Background(), &stats.RPCTagInfo{FullMethodName: "/test.Service/Unary"})
+	for _, rs := range []stats.RPCStats{
+		&stats.Begin{Client: true},
+		&stats.OutPayload{Client: true, Length: 5, CompressedLength: 5},
+		&stats.InPayload{Client: true, Length: 7, CompressedLength: 7},
+		&stats.End{Client: true},
+	} {
+		handler.HandleRPC(ctx, rs)
+	}
+
+	spans := rec.Flush()
+	if want, have := 1, len(spans); want != have {
+		t.Fatalf("unexpected number of spans, want %d, have %d", want, have)
+	}
+	if want, have := "5", spans[0].Tags[zipkingrpc.TagGRPCRequestSize]; want != have {
+		t.Errorf("unexpected request size tag, want %s, have %s", want, have)
+	}
+	if want, have := "7", spans[0].Tags[zipkingrpc.TagGRPCResponseSize]; want != have {
+		t.Errorf("unexpected response size tag, want %s, have %s", want, have)
+	}
+	if _, ok := spans[0].Tags[zipkingrpc.TagGRPCRequestMessages]; ok {
+		t.Error("unexpected message count tag for unary RPC")
+	}
+	if want, have := 0, len(spans[0].Annotations); want != have {
+		t.Errorf("unexpected annotations for unary RPC: %+v", spans[0].Annotations)
+	}
+}
@@ -0,0 +1,77 @@
+// Copyright 2022 The OpenZipkin Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grpc
+
+import (
+	"context"
+	"fmt"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/openzipkin/zipkin-go"
+	"github.com/openzipkin/zipkin-go/middleware"
+)
+
+// UnaryServerPanicInterceptor returns a grpc.UnaryServerInterceptor which
+// recovers panics of unary handlers and records them on the server span
+// created by the server handler. The panic value is tagged as error and the
+// (truncated) stack trace is annotated. Depending on the provided mode the
+// panic is re-raised after finishing the span or the call fails with status
+// code INTERNAL.
+func UnaryServerPanicInterceptor(mode middleware.PanicMode) grpc.UnaryServerInterceptor {
+	return func(
+		ctx context.Context, req interface{}, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler,
+	) (resp interface{}, err error) {
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				err = handlePanic(ctx, mode, recovered)
+			}
+		}()
+		return handler(ctx, req)
+	}
+}
+
+// StreamServerPanicInterceptor returns a grpc.StreamServerInterceptor which
+// recovers panics of stream handlers. See UnaryServerPanicInterceptor.
+func StreamServerPanicInterceptor(mode middleware.PanicMode) grpc.StreamServerInterceptor {
+	return func(
+		srv interface{}, ss grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler,
+	) (err error) {
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				err = handlePanic(ss.Context(), mode, recovered)
+			}
+		}()
+		return handler(srv, ss)
+	}
+}
+
+// handlePanic records the recovered panic on the span found in context and
+// either re-panics or returns the error to respond with.
+func handlePanic(ctx context.Context, mode middleware.PanicMode, recovered interface{}) error {
+	span := zipkin.SpanOrNoopFromContext(ctx)
+	if !zipkin.IsNoop(span) {
+		middleware.RecordPanic(span, recovered)
+	}
+	if mode == middleware.PanicRepanic {
+		// the stats handler won't see the end of the RPC, finish the span
+		span.Tag(string(zipkin.TagGRPCStatusCode), "INTERNAL")
+		span.Finish()
+		panic(recovered)
+	}
+	return status.Error(codes.Internal, fmt.Sprint(recovered))
+}
@@ -0,0 +1,90 @@
+// Copyright 2022 The OpenZipkin Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grpc_test
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/openzipkin/zipkin-go"
+	"github.com/openzipkin/zipkin-go/middleware"
+	zipkingrpc "github.com/openzipkin/zipkin-go/middleware/grpc"
+	service "github.com/openzipkin/zipkin-go/proto/testing"
+	"github.com/openzipkin/zipkin-go/reporter/recorder"
+)
+
+type panicHelloService struct {
+	service.UnimplementedHelloServiceServer
+}
+
+func (s *panicHelloService) Hello(context.Context, *service.HelloRequest) (*service.HelloResponse, error) {
+	panic("boom")
+}
+
+func TestGRPCServerPanicInterceptor(t *testing.T) {
+	var (
+		rec       = recorder.NewReporter()
+		tracer, _ = zipkin.NewTracer(rec)
+		gSrv      = grpc.NewServer(
+			grpc.StatsHandler(zipkingrpc.NewServerHandler(tracer)),
+			grpc.UnaryInterceptor(zipkingrpc.UnaryServerPanicInterceptor(middleware.PanicRecover)),
+		)
+	)
+	defer rec.Close()
+
+	ln, err := net.Listen("tcp", ":0")
+	if err != nil {
+		t.Fatalf("unable to create listener for grpc server: %+v", err)
+	}
+	service.RegisterHelloServiceServer(gSrv, &panicHelloService{})
+	go func() {
+		_ = gSrv.Serve(ln)
+	}()
+	defer gSrv.Stop()
+
+	conn, err := grpc.Dial(ln.Addr().String(), grpc.WithInsecure())
+	if err != nil {
+		t.Fatalf("unable to create connection for grpc client: %+v", err)
+	}
+	defer func() {
+		_ = conn.Close()
+	}()
+
+	_, err = service.NewHelloServiceClient(conn).Hello(context.Background(), &service.HelloRequest{Payload: "Hello"})
+	if want, have := codes.Internal, status.Code(err); want != have {
+		t.Fatalf("unexpected status code, want %s, have %s", want, have)
+	}
+
+	gSrv.GracefulStop()
+
+	spans := rec.Flush()
+	if want, have := 1, len(spans); want != have {
+		t.Fatalf("unexpected number of spans, want %d, have %d", want, have)
+	}
+	if want, have := "boom", spans[0].Tags[string(zipkin.TagError)]; want != have {
+		t.Errorf("unexpected error tag, want %s, have %s", want, have)
+	}
+	if want, have := "INTERNAL", spans[0].Tags[string(zipkin.TagGRPCStatusCode)]; want != have {
+		t.Errorf("unexpected status code tag, want %s, have %s", want, have)
+	}
+	if len(spans[0].Annotations) != 1 || len(spans[0].Annotations[0].Value) > len("panic: ")+middleware.MaxPanicStackSize {
+		t.Errorf("expected single truncated panic annotation, have %+v", spans[0].Annotations)
+	}
+}
@@ -27,9 +27,12 @@ import (
 )
 
 type serverHandler struct {
-	tracer      *zipkin.Tracer
-	defaultTags map[string]string
-	baggage     middleware.BaggageHandler
+	tracer       *zipkin.Tracer
+	defaultTags  map[string]string
+	baggage      middleware.BaggageHandler
+	resolver     middleware.ClientAddressResolver
+	rpcHandlers  []RPCHandler
+	messageStats bool
 }
 
 // A ServerOption can be passed to NewServerHandler to customize the returned handler.
@@ -50,6 +53,32 @@ func EnableBaggage(b middleware.BaggageHandler) ServerOption {
 	}
 }
 
+// ServerRemoteEndpointResolver allows one to resolve the remote endpoint of
+// server spans from forwarding metadata set by proxies in front of the
+// service. The proxy address is recorded in the proxy.address tag.
+func ServerRemoteEndpointResolver(resolver middleware.ClientAddressResolver) ServerOption {
+	return func(h *serverHandler) {
+		h.resolver = resolver
+	}
+}
+
+// WithServerRPCHandler allows one to add a RPCHandler which is called for all
+// RPC stats of recorded server spans, before the span is finished.
+func WithServerRPCHandler(handler RPCHandler) ServerOption {
+	return func(h *serverHandler) {
+		h.rpcHandlers = append(h.rpcHandlers, handler)
+	}
+}
+
+// WithServerMessageStats allows one to record message level stats on server
+// spans: request and response sizes, compression, message counts for
+// streaming RPCs and per-message annotations for streaming RPCs.
+func WithServerMessageStats(enabled bool) ServerOption {
+	return func(h *serverHandler) {
+		h.messageStats = enabled
+	}
+}
+
 // NewServerHandler returns a stats.Handler which can be used with grpc.WithStatsHandler to add
 // tracing to a gRPC server. The gRPC method name is used as the span name and by default the only
 // tags are the gRPC status code if the call fails. Use ServerTags to add additional tags that
@@ -77,7 +106,7 @@ func (s *serverHandler) TagConn(ctx context.Context, _ *stats.ConnTagInfo) conte
 
 // HandleRPC implements per-RPC tracing and stats instrumentation.
 func (s *serverHandler) HandleRPC(ctx context.Context, rs stats.RPCStats) {
-	handleRPC(ctx, rs)
+	handleRPC(ctx, rs, s.rpcHandlers)
 }
 
 // TagRPC implements per-RPC context management.
@@ -96,7 +125,16 @@ func (s *serverHandler) TagRPC(ctx context.Context, rti *stats.RPCTagInfo) conte
 	if s.baggage != nil {
 		spanContext.Baggage = s.baggage.New()
 		for key, values := range md {
-			spanContext.Baggage.Add(key, values...)
+			middleware.ExtractBaggage(spanContext.Baggage, key, values...)
+		}
+	}
+
+	remoteEndpoint := remoteEndpointFromContext(ctx, "")
+	var proxyAddr string
+	if s.resolver != nil {
+		if ep := resolveRemoteEndpoint(ctx, s.resolver, md); ep != nil {
+			proxyAddr = remoteAddrFromContext(ctx)
+			remoteEndpoint = ep
 		}
 	}
 
@@ -104,14 +142,21 @@ func (s *serverHandler) TagRPC(ctx context.Context, rti *stats.RPCTagInfo) conte
 		name,
 		zipkin.Kind(model.Server),
 		zipkin.Parent(spanContext),
-		zipkin.RemoteEndpoint(remoteEndpointFromContext(ctx, "")),
+		zipkin.RemoteEndpoint(remoteEndpoint),
 	)
 
 	if !zipkin.IsNoop(span) {
 		for k, v := range s.defaultTags {
 			span.Tag(k, v)
 		}
+		if proxyAddr != "" {
+			span.Tag(middleware.TagProxyAddress, proxyAddr)
+		}
 	}
 
-	return zipkin.NewContext(ctx, span)
+	ctx = zipkin.NewContext(ctx, span)
+	if s.messageStats {
+		ctx = withRPCState(ctx)
+	}
+	return ctx
 }
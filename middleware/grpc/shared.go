@@ -16,14 +16,17 @@ package grpc
 
 import (
 	"context"
+	"net"
 	"strings"
 
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/peer"
 	"google.golang.org/grpc/stats"
 	"google.golang.org/grpc/status"
 
 	"github.com/openzipkin/zipkin-go"
+	"github.com/openzipkin/zipkin-go/middleware"
 	"github.com/openzipkin/zipkin-go/model"
 )
 
@@ -37,12 +40,20 @@ func spanName(rti *stats.RPCTagInfo) string {
 	return name
 }
 
-func handleRPC(ctx context.Context, rs stats.RPCStats) {
+func handleRPC(ctx context.Context, rs stats.RPCStats, handlers []RPCHandler) {
 	span := zipkin.SpanFromContext(ctx)
 	if zipkin.IsNoop(span) {
 		return
 	}
 
+	if state := rpcStateFromContext(ctx); state != nil {
+		state.handle(span, rs)
+	}
+
+	for _, handler := range handlers {
+		handler(span, rs)
+	}
+
 	switch rs := rs.(type) {
 	case *stats.End:
 		s, ok := status.FromError(rs.Error)
@@ -62,13 +73,28 @@ func handleRPC(ctx context.Context, rs stats.RPCStats) {
 }
 
 func remoteEndpointFromContext(ctx context.Context, name string) *model.Endpoint {
-	remoteAddr := ""
+	ep, _ := zipkin.NewEndpoint(name, remoteAddrFromContext(ctx))
+	return ep
+}
 
-	p, ok := peer.FromContext(ctx)
-	if ok {
-		remoteAddr = p.Addr.String()
+func remoteAddrFromContext(ctx context.Context) string {
+	if p, ok := peer.FromContext(ctx); ok {
+		return p.Addr.String()
 	}
+	return ""
+}
 
-	ep, _ := zipkin.NewEndpoint(name, remoteAddr)
+// resolveRemoteEndpoint returns the endpoint of the originating client if it
+// can be resolved from the forwarding metadata.
+func resolveRemoteEndpoint(ctx context.Context, resolver middleware.ClientAddressResolver, md metadata.MD) *model.Endpoint {
+	host, _, err := net.SplitHostPort(remoteAddrFromContext(ctx))
+	if err != nil {
+		return nil
+	}
+	ip := resolver(net.ParseIP(host), md.Get)
+	if ip == nil {
+		return nil
+	}
+	ep, _ := zipkin.NewEndpoint("", net.JoinHostPort(ip.String(), "0"))
 	return ep
 }
@@ -0,0 +1,157 @@
+// Copyright 2022 The OpenZipkin Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"sync"
+
+	"github.com/openzipkin/zipkin-go"
+)
+
+// Tags used on client spans of tracked attempts.
+const (
+	TagHTTPURL            = "http.url"
+	TagHTTPAttempt        = "http.attempt"
+	TagHTTPRedirectStatus = "http.redirect_status"
+)
+
+// maxRedirects mirrors the redirect limit of http.Client.
+const maxRedirects = 10
+
+// attempts tracks the round trips of a logical request.
+type attempts struct {
+	mtx            sync.Mutex
+	count          int
+	redirectStatus int
+}
+
+type attemptsKey struct{}
+
+func withAttempts(ctx context.Context) context.Context {
+	return context.WithValue(ctx, attemptsKey{}, &attempts{})
+}
+
+func attemptsFromContext(ctx context.Context) *attempts {
+	if a, ok := ctx.Value(attemptsKey{}).(*attempts); ok {
+		return a
+	}
+	return nil
+}
+
+// next registers a new attempt and returns its number and the status code of
+// the redirect response which caused it, if any.
+func (a *attempts) next() (int, int) {
+	a.mtx.Lock()
+	defer a.mtx.Unlock()
+	a.count++
+	redirectStatus := a.redirectStatus
+	a.redirectStatus = 0
+	return a.count, redirectStatus
+}
+
+func (a *attempts) redirected(statusCode int) {
+	a.mtx.Lock()
+	defer a.mtx.Unlock()
+	a.redirectStatus = statusCode
+}
+
+// ClientTrackAttempts allows one to record each redirect hop and retry
+// attempt of a request done with DoWithAppSpan as a separate client span,
+// child of the application span. Client spans are tagged with the URL, the
+// attempt number and the status code of the redirect response leading to
+// the attempt.
+func ClientTrackAttempts(enabled bool) ClientOption {
+	return func(c *Client) {
+		c.trackAttempts = enabled
+	}
+}
+
+// trackRedirects wraps the CheckRedirect policy of the http.Client so the
+// redirect status code is recorded on the next attempt.
+func trackRedirects(checkRedirect func(*http.Request, []*http.Request) error) func(*http.Request, []*http.Request) error {
+	return func(req *http.Request, via []*http.Request) error {
+		if a := attemptsFromContext(req.Context()); a != nil && req.Response != nil {
+			a.redirected(req.Response.StatusCode)
+		}
+		if checkRedirect != nil {
+			return checkRedirect(req, via)
+		}
+		if len(via) >= maxRedirects {
+			return errors.New("stopped after 10 redirects")
+		}
+		return nil
+	}
+}
+
+type attemptTransport struct {
+	tracer *zipkin.Tracer
+	rt     http.RoundTripper
+}
+
+// NewAttemptTransport returns a RoundTripper which records a logical request
+// span for each request and tracks the round trips of the wrapped RoundTripper
+// as attempts. It allows one to wrap a retrying RoundTripper, which in turn
+// wraps a RoundTripper created by NewTransport, so each retry is recorded as
+// a separate client span, child of the logical request span.
+func NewAttemptTransport(tracer *zipkin.Tracer, rt http.RoundTripper) (http.RoundTripper, error) {
+	if tracer == nil {
+		return nil, ErrValidTracerRequired
+	}
+	if rt == nil {
+		rt = http.DefaultTransport
+	}
+	return &attemptTransport{tracer: tracer, rt: rt}, nil
+}
+
+// RoundTrip satisfies the RoundTripper interface.
+func (t *attemptTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	sp, ctx := t.tracer.StartSpanFromContext(req.Context(), clientSpanName(req))
+	zipkin.TagHTTPMethod.Set(sp, req.Method)
+	sp.Tag(TagHTTPURL, req.URL.Redacted())
+
+	res, err := t.rt.RoundTrip(req.WithContext(withAttempts(ctx)))
+	if err != nil {
+		zipkin.TagError.Set(sp, err.Error())
+		sp.Finish()
+		return res, err
+	}
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		statusCode := strconv.FormatInt(int64(res.StatusCode), 10)
+		zipkin.TagHTTPStatusCode.Set(sp, statusCode)
+		if res.StatusCode > 399 {
+			zipkin.TagError.Set(sp, statusCode)
+		}
+	}
+
+	res.Body = &spanCloser{
+		ReadCloser: res.Body,
+		sp:         sp,
+	}
+	return res, nil
+}
+
+// tagAttempt tags the client span with the attempt details.
+func tagAttempt(sp zipkin.Span, req *http.Request, n, redirectStatus int) {
+	sp.Tag(TagHTTPURL, req.URL.Redacted())
+	sp.Tag(TagHTTPAttempt, strconv.Itoa(n))
+	if redirectStatus > 0 {
+		sp.Tag(TagHTTPRedirectStatus, strconv.Itoa(redirectStatus))
+	}
+}
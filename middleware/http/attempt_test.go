@@ -0,0 +1,156 @@
+// Copyright 2022 The OpenZipkin Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"testing"
+
+	"github.com/openzipkin/zipkin-go"
+	mw "github.com/openzipkin/zipkin-go/middleware/http"
+	"github.com/openzipkin/zipkin-go/model"
+	"github.com/openzipkin/zipkin-go/reporter/recorder"
+)
+
+// clientSpans returns the client spans sorted by attempt.
+func clientSpans(spans []model.SpanModel) []model.SpanModel {
+	var res []model.SpanModel
+	for _, span := range spans {
+		if span.Kind == model.Client {
+			res = append(res, span)
+		}
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Tags[mw.TagHTTPAttempt] < res[j].Tags[mw.TagHTTPAttempt]
+	})
+	return res
+}
+
+func TestClientTrackAttemptsRedirect(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/start", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/final", http.StatusFound)
+	})
+	mux.HandleFunc("/final", func(w http.ResponseWriter, r *http.Request) {})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	var (
+		spanRecorder = &recorder.ReporterRecorder{}
+		tr, _        = zipkin.NewTracer(spanRecorder)
+		client, _    = mw.NewClient(tr, mw.ClientTrackAttempts(true))
+	)
+
+	req, _ := http.NewRequest("GET", srv.URL+"/start", nil)
+	res, err := client.DoWithAppSpan(req, "logical")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	_ = res.Body.Close()
+
+	spans := spanRecorder.Flush()
+	if want, have := 3, len(spans); want != have {
+		t.Fatalf("unexpected number of spans, want %d, have %d", want, have)
+	}
+	var appSpan model.SpanModel
+	for _, span := range spans {
+		if span.Name == "logical" {
+			appSpan = span
+		}
+	}
+
+	attempts := clientSpans(spans)
+	for i, span := range attempts {
+		if span.ParentID == nil || *span.ParentID != appSpan.ID {
+			t.Errorf("[%d] expected attempt to be a child of the application span", i)
+		}
+	}
+	if want, have := "1", attempts[0].Tags[mw.TagHTTPAttempt]; want != have {
+		t.Errorf("unexpected attempt tag, want %s, have %s", want, have)
+	}
+	if want, have := srv.URL+"/start", attempts[0].Tags[mw.TagHTTPURL]; want != have {
+		t.Errorf("unexpected url tag, want %s, have %s", want, have)
+	}
+	if want, have := "2", attempts[1].Tags[mw.TagHTTPAttempt]; want != have {
+		t.Errorf("unexpected attempt tag, want %s, have %s", want, have)
+	}
+	if want, have := srv.URL+"/final", attempts[1].Tags[mw.TagHTTPURL]; want != have {
+		t.Errorf("unexpected url tag, want %s, have %s", want, have)
+	}
+	if want, have := "302", attempts[1].Tags[mw.TagHTTPRedirectStatus]; want != have {
+		t.Errorf("unexpected redirect status tag, want %s, have %s", want, have)
+	}
+}
+
+type retryTransport struct {
+	rt http.RoundTripper
+}
+
+func (r retryTransport) RoundTrip(req *http.Request) (res *http.Response, err error) {
+	for i := 0; i < 3; i++ {
+		if res, err = r.rt.RoundTrip(req); err == nil && res.StatusCode != http.StatusServiceUnavailable {
+			return res, nil
+		}
+		if res != nil {
+			_ = res.Body.Close()
+		}
+	}
+	return res, err
+}
+
+func TestAttemptTransportRetries(t *testing.T) {
+	var calls int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls++; calls < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer srv.Close()
+
+	var (
+		spanRecorder = &recorder.ReporterRecorder{}
+		tr, _        = zipkin.NewTracer(spanRecorder)
+		transport, _ = mw.NewTransport(tr)
+		attempts, _  = mw.NewAttemptTransport(tr, retryTransport{rt: transport})
+		client       = &http.Client{Transport: attempts}
+	)
+
+	res, err := client.Get(srv.URL)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	_ = res.Body.Close()
+
+	spans := spanRecorder.Flush()
+	if want, have := 4, len(spans); want != have {
+		t.Fatalf("unexpected number of spans, want %d, have %d", want, have)
+	}
+	for i, span := range clientSpans(spans) {
+		if want, have := string(rune('1'+i)), span.Tags[mw.TagHTTPAttempt]; want != have {
+			t.Errorf("[%d] unexpected attempt tag, want %s, have %s", i, want, have)
+		}
+		if span.ParentID == nil {
+			t.Errorf("[%d] expected attempt to have a parent", i)
+		}
+	}
+}
+
+func TestNewAttemptTransportRequiresTracer(t *testing.T) {
+	if _, err := mw.NewAttemptTransport(nil, nil); err != mw.ErrValidTracerRequired {
+		t.Errorf("unexpected error, want %v, have %v", mw.ErrValidTracerRequired, err)
+	}
+}
@@ -17,6 +17,7 @@ package http_test
 import (
 	"net"
 	"net/http"
+	"net/http/httptest"
 	"testing"
 
 	"github.com/openzipkin/zipkin-go"
@@ -89,6 +90,58 @@ func TestHTTPBaggage(t *testing.T) {
 
 }
 
+func TestHTTPBaggageFieldDefinitions(t *testing.T) {
+	var (
+		tracer, _  = zipkin.NewTracer(nil)
+		tr, _      = zipkinhttp.NewTransport(tracer)
+		cli        = &http.Client{Transport: tr}
+		bagHandler = baggage.NewWithFields(
+			append(baggage.PrefixedFields("baggage-", "user-id"), baggage.LocalField("secret"))...,
+		)
+		received http.Header
+	)
+
+	downstream := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		received = r.Header
+	}))
+	defer downstream.Close()
+
+	var localValue []string
+	upstream := httptest.NewServer(zipkinhttp.NewServerMiddleware(
+		tracer,
+		zipkinhttp.EnableBaggage(bagHandler),
+	)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fields := zipkin.BaggageFromContext(r.Context())
+		fields.Set("secret", "do-not-propagate")
+		localValue = fields.Get("user-id")
+		req, _ := http.NewRequestWithContext(r.Context(), "GET", downstream.URL, nil)
+		if _, err := cli.Do(req); err != nil {
+			http.Error(w, http.StatusText(500), 500)
+		}
+	})))
+	defer upstream.Close()
+
+	req, _ := http.NewRequest("GET", upstream.URL, nil)
+	req.Header.Set("Baggage-User-Id", "user-1")
+	req.Header.Set("Secret", "injected")
+	if _, err := http.DefaultClient.Do(req); err != nil {
+		t.Fatalf("unexpected http request error: %+v", err)
+	}
+
+	if len(localValue) != 1 || localValue[0] != "user-1" {
+		t.Errorf("expected local user-id field: want %s, have %v", "user-1", localValue)
+	}
+	if want, have := "user-1", received.Get("Baggage-User-Id"); want != have {
+		t.Errorf("expected propagated baggage-user-id: want %s, have %s", want, have)
+	}
+	if have := received.Get("User-Id"); have != "" {
+		t.Errorf("expected no unprefixed user-id header, have %s", have)
+	}
+	if have := received.Get("Secret"); have != "" {
+		t.Errorf("expected local only field to not be propagated, have %s", have)
+	}
+}
+
 type server struct {
 	s               *http.Server
 	c               *http.Client
@@ -0,0 +1,142 @@
+// Copyright 2022 The OpenZipkin Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http
+
+import (
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	zipkin "github.com/openzipkin/zipkin-go"
+)
+
+// TagHTTPResponseBytesRead holds the number of response body bytes read by
+// the caller.
+const TagHTTPResponseBytesRead = "http.response.bytes_read"
+
+// DefaultBodyTimeout is used by TransportFinishOnBodyClose if no valid timeout
+// is provided.
+const DefaultBodyTimeout = 5 * time.Minute
+
+// TransportFinishOnBodyClose makes client spans end when the response body
+// is read until EOF or closed instead of when the response headers arrive.
+// The number of bytes read and any read error are tagged. If the body is not
+// consumed within the provided timeout the span is finished regardless.
+func TransportFinishOnBodyClose(timeout time.Duration) TransportOption {
+	return func(t *transport) {
+		if timeout <= 0 {
+			timeout = DefaultBodyTimeout
+		}
+		t.bodyTimeout = timeout
+	}
+}
+
+// finishOnBodyClose wraps the response body to finish the span once the body
+// is consumed, capturing the body if enabled. It returns false if the span
+// should be finished right away.
+func (t *transport) finishOnBodyClose(res *http.Response, sp zipkin.Span) bool {
+	if !hasBody(res.Body) {
+		return false
+	}
+	bodyTimeout := t.bodyTimeout
+	var onFinish func()
+	if resBody := t.bodyCapture.newBuffer(sp, res.Header.Get("Content-Type")); resBody != nil {
+		res.Body = &captureReader{ReadCloser: res.Body, buf: resBody}
+		onFinish = func() {
+			t.bodyCapture.record(sp, TagHTTPResponseBody, resBody)
+		}
+		if bodyTimeout <= 0 {
+			bodyTimeout = DefaultBodyTimeout
+		}
+	}
+	if bodyTimeout <= 0 {
+		return false
+	}
+	res.Body = newBodyTracker(sp, res.Body, bodyTimeout, onFinish)
+	return true
+}
+
+// bodyTracker finishes the span once the wrapped body is consumed.
+type bodyTracker struct {
+	io.ReadCloser
+	sp       zipkin.Span
+	timer    *time.Timer
+	once     sync.Once
+	read     int64
+	onFinish func()
+}
+
+// rwBodyTracker retains the io.Writer of bodies returned for protocol
+// switches.
+type rwBodyTracker struct {
+	*bodyTracker
+	io.Writer
+}
+
+func newBodyTracker(sp zipkin.Span, body io.ReadCloser, timeout time.Duration, onFinish func()) io.ReadCloser {
+	bt := &bodyTracker{ReadCloser: body, sp: sp, onFinish: onFinish}
+	bt.timer = time.AfterFunc(timeout, func() {
+		bt.once.Do(func() {
+			bt.sp.Annotate(time.Now(), "body timeout")
+			bt.finishSpan()
+		})
+	})
+	if w, ok := body.(io.Writer); ok {
+		return rwBodyTracker{bodyTracker: bt, Writer: w}
+	}
+	return bt
+}
+
+func (b *bodyTracker) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	atomic.AddInt64(&b.read, int64(n))
+	switch err {
+	case nil:
+	case io.EOF:
+		b.finish(nil)
+	default:
+		b.finish(func() {
+			zipkin.TagError.Set(b.sp, err.Error())
+		})
+	}
+	return n, err
+}
+
+func (b *bodyTracker) Close() error {
+	err := b.ReadCloser.Close()
+	b.finish(nil)
+	return err
+}
+
+func (b *bodyTracker) finish(f func()) {
+	b.once.Do(func() {
+		b.timer.Stop()
+		if f != nil {
+			f()
+		}
+		b.finishSpan()
+	})
+}
+
+func (b *bodyTracker) finishSpan() {
+	if b.onFinish != nil {
+		b.onFinish()
+	}
+	b.sp.Tag(TagHTTPResponseBytesRead, strconv.FormatInt(atomic.LoadInt64(&b.read), 10))
+	b.sp.Finish()
+}
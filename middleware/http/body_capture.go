@@ -0,0 +1,208 @@
+// Copyright 2022 The OpenZipkin Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http
+
+import (
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/openzipkin/zipkin-go"
+)
+
+// Body capture tag keys
+const (
+	TagHTTPRequestBody  = "http.request.body"
+	TagHTTPResponseBody = "http.response.body"
+)
+
+// DefaultMaxBodyCaptureBytes is used by BodyCapture if MaxBytes is not set.
+const DefaultMaxBodyCaptureBytes = 1024
+
+// BodyRedactor returns the body to record for a captured body of the provided
+// content type.
+type BodyRedactor func(contentType string, body []byte) []byte
+
+// BodyCapture holds the configuration for capturing request and response
+// bodies on recorded spans. Bodies are captured while they are read or
+// written, so streaming is not affected and at most MaxBytes of each body is
+// held in memory.
+type BodyCapture struct {
+	// ContentTypes holds the allowlist of media types to capture. Wildcard
+	// subtypes like "text/*" are supported.
+	ContentTypes []string
+	// MaxBytes caps the number of captured bytes per body. Captured bodies
+	// exceeding the cap are truncated and marked with a "[truncated]" suffix.
+	// If 0, DefaultMaxBodyCaptureBytes is used.
+	MaxBytes int
+	// DebugOnly restricts capture to debug requests. Otherwise all sampled
+	// requests are captured.
+	DebugOnly bool
+	// Redact is called with the captured body before it is recorded.
+	Redact BodyRedactor
+	// Annotate records captured bodies as annotations instead of tags.
+	Annotate bool
+}
+
+// ServerCaptureBody allows one to capture request and response bodies on
+// server spans.
+func ServerCaptureBody(bc BodyCapture) ServerOption {
+	return func(h *handler) {
+		h.bodyCapture = newBodyCapture(bc)
+	}
+}
+
+// TransportCaptureBody allows one to capture request and response bodies on
+// client spans. Capturing response bodies requires the client span to end
+// when the response body is consumed, see TransportFinishOnBodyClose. If not
+// configured, DefaultBodyTimeout is used for responses being captured.
+func TransportCaptureBody(bc BodyCapture) TransportOption {
+	return func(t *transport) {
+		t.bodyCapture = newBodyCapture(bc)
+	}
+}
+
+type bodyCapture struct {
+	BodyCapture
+}
+
+func newBodyCapture(bc BodyCapture) *bodyCapture {
+	if bc.MaxBytes <= 0 {
+		bc.MaxBytes = DefaultMaxBodyCaptureBytes
+	}
+	return &bodyCapture{BodyCapture: bc}
+}
+
+// allowed returns true if a body of the provided content type should be
+// captured for the span.
+func (c *bodyCapture) allowed(sp zipkin.Span, contentType string) bool {
+	sc := sp.Context()
+	if !sc.Debug && (c.DebugOnly || sc.Sampled == nil || !*sc.Sampled) {
+		return false
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	for _, allowed := range c.ContentTypes {
+		allowed = strings.ToLower(allowed)
+		if allowed == mediaType {
+			return true
+		}
+		if strings.HasSuffix(allowed, "/*") && strings.HasPrefix(mediaType, allowed[:len(allowed)-1]) {
+			return true
+		}
+	}
+	return false
+}
+
+// newBuffer returns a capture buffer for a body of the provided content type,
+// or nil if the body should not be captured.
+func (c *bodyCapture) newBuffer(sp zipkin.Span, contentType string) *captureBuffer {
+	if c == nil || !c.allowed(sp, contentType) {
+		return nil
+	}
+	return &captureBuffer{max: c.MaxBytes, contentType: contentType}
+}
+
+// captureRequest wraps the request body to capture it for the span. It
+// returns a shallow copy of the request holding the wrapped body and the
+// capture buffer, or the unmodified request and nil if the body is not
+// captured.
+func (c *bodyCapture) captureRequest(sp zipkin.Span, req *http.Request) (*http.Request, *captureBuffer) {
+	if !hasBody(req.Body) {
+		return req, nil
+	}
+	buf := c.newBuffer(sp, req.Header.Get("Content-Type"))
+	if buf == nil {
+		return req, nil
+	}
+	// don't modify the caller's request
+	req = req.WithContext(req.Context())
+	req.Body = &captureReader{ReadCloser: req.Body, buf: buf}
+	return req, buf
+}
+
+// record stores the captured body on the span.
+func (c *bodyCapture) record(sp zipkin.Span, key string, buf *captureBuffer) {
+	if buf == nil {
+		return
+	}
+	body, truncated := buf.snapshot()
+	if len(body) == 0 {
+		return
+	}
+	if c.Redact != nil {
+		body = c.Redact(buf.contentType, body)
+	}
+	value := string(body)
+	if truncated {
+		value += "[truncated]"
+	}
+	if c.Annotate {
+		sp.Annotate(time.Now(), key+": "+value)
+		return
+	}
+	sp.Tag(key, value)
+}
+
+// captureBuffer holds up to max bytes of a body.
+type captureBuffer struct {
+	mtx         sync.Mutex
+	buf         []byte
+	max         int
+	truncated   bool
+	contentType string
+}
+
+// Write implements io.Writer and never fails so it can be used with
+// io.TeeReader.
+func (b *captureBuffer) Write(p []byte) (int, error) {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+	if remaining := b.max - len(b.buf); len(p) > remaining {
+		b.buf = append(b.buf, p[:remaining]...)
+		b.truncated = true
+	} else {
+		b.buf = append(b.buf, p...)
+	}
+	return len(p), nil
+}
+
+func (b *captureBuffer) snapshot() ([]byte, bool) {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+	return append([]byte(nil), b.buf...), b.truncated
+}
+
+// captureReader captures the body read through it.
+type captureReader struct {
+	io.ReadCloser
+	buf *captureBuffer
+}
+
+func (r *captureReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	_, _ = r.buf.Write(p[:n])
+	return n, err
+}
+
+// hasBody returns true if body holds a request or response body.
+func hasBody(body io.ReadCloser) bool {
+	return body != nil && body != http.NoBody
+}
@@ -0,0 +1,181 @@
+// Copyright 2022 The OpenZipkin Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http_test
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/openzipkin/zipkin-go"
+	mw "github.com/openzipkin/zipkin-go/middleware/http"
+	"github.com/openzipkin/zipkin-go/reporter/recorder"
+)
+
+func echoHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", r.Header.Get("Content-Type"))
+	_, _ = io.Copy(w, r.Body)
+}
+
+func TestHTTPServerCaptureBody(t *testing.T) {
+	var (
+		spanRecorder = &recorder.ReporterRecorder{}
+		tr, _        = zipkin.NewTracer(spanRecorder)
+		handler      = mw.NewServerMiddleware(tr, mw.ServerCaptureBody(mw.BodyCapture{
+			ContentTypes: []string{"application/json"},
+			MaxBytes:     10,
+			Redact: func(_ string, body []byte) []byte {
+				return bytes.ReplaceAll(body, []byte("secret"), []byte("******"))
+			},
+		}))(http.HandlerFunc(echoHandler))
+	)
+
+	r := httptest.NewRequest("POST", "/", strings.NewReader(`{"secret":"value"}`))
+	r.Header.Set("Content-Type", "application/json; charset=utf-8")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+
+	if want, have := `{"secret":"value"}`, w.Body.String(); want != have {
+		t.Errorf("unexpected response body, want %s, have %s", want, have)
+	}
+
+	spans := spanRecorder.Flush()
+	if want, have := 1, len(spans); want != have {
+		t.Fatalf("unexpected number of spans, want %d, have %d", want, have)
+	}
+	for _, key := range []string{mw.TagHTTPRequestBody, mw.TagHTTPResponseBody} {
+		if want, have := `{"******":[truncated]`, spans[0].Tags[key]; want != have {
+			t.Errorf("unexpected %s tag, want %s, have %s", key, want, have)
+		}
+	}
+
+	// content types not in the allowlist are not captured
+	r = httptest.NewRequest("POST", "/", strings.NewReader("binary"))
+	r.Header.Set("Content-Type", "application/octet-stream")
+	handler.ServeHTTP(httptest.NewRecorder(), r)
+	spans = spanRecorder.Flush()
+	if _, ok := spans[0].Tags[mw.TagHTTPRequestBody]; ok {
+		t.Errorf("unexpected request body tag: %+v", spans[0].Tags)
+	}
+}
+
+func TestHTTPServerCaptureBodyDebugOnly(t *testing.T) {
+	var (
+		spanRecorder = &recorder.ReporterRecorder{}
+		tr, _        = zipkin.NewTracer(spanRecorder)
+		handler      = mw.NewServerMiddleware(tr, mw.ServerCaptureBody(mw.BodyCapture{
+			ContentTypes: []string{"text/*"},
+			DebugOnly:    true,
+			Annotate:     true,
+		}))(http.HandlerFunc(echoHandler))
+	)
+
+	for _, debug := range []bool{false, true} {
+		r := httptest.NewRequest("POST", "/", strings.NewReader("hello"))
+		r.Header.Set("Content-Type", "text/plain")
+		if debug {
+			r.Header.Set("X-B3-Flags", "1")
+		}
+		handler.ServeHTTP(httptest.NewRecorder(), r)
+
+		spans := spanRecorder.Flush()
+		var captured int
+		for _, annotation := range spans[0].Annotations {
+			if strings.HasSuffix(annotation.Value, ": hello") {
+				captured++
+			}
+		}
+		if want, have := 0, captured; debug {
+			if want = 2; want != have {
+				t.Errorf("expected request and response body annotations, have %+v", spans[0].Annotations)
+			}
+		} else if want != have {
+			t.Errorf("unexpected body annotations: %+v", spans[0].Annotations)
+		}
+	}
+}
+
+func TestTransportCaptureBody(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(echoHandler))
+	defer srv.Close()
+
+	var (
+		spanRecorder = &recorder.ReporterRecorder{}
+		tr, _        = zipkin.NewTracer(spanRecorder)
+		transport, _ = mw.NewTransport(tr, mw.TransportCaptureBody(mw.BodyCapture{
+			ContentTypes: []string{"application/json"},
+		}))
+		client = &http.Client{Transport: transport}
+	)
+
+	res, err := client.Post(srv.URL, "application/json", strings.NewReader(`{"a":1}`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	body, _ := ioutil.ReadAll(res.Body)
+	_ = res.Body.Close()
+	if want, have := `{"a":1}`, string(body); want != have {
+		t.Errorf("unexpected response body, want %s, have %s", want, have)
+	}
+
+	// the client span ends once the captured response body is consumed
+	spans := spanRecorder.Flush()
+	if want, have := 1, len(spans); want != have {
+		t.Fatalf("unexpected number of spans, want %d, have %d", want, have)
+	}
+	for _, key := range []string{mw.TagHTTPRequestBody, mw.TagHTTPResponseBody} {
+		if want, have := `{"a":1}`, spans[0].Tags[key]; want != have {
+			t.Errorf("unexpected %s tag, want %s, have %s", key, want, have)
+		}
+	}
+}
+
+func TestHTTPServerCaptureBodyUnsampled(t *testing.T) {
+	var (
+		spanRecorder = &recorder.ReporterRecorder{}
+		tr, _        = zipkin.NewTracer(spanRecorder)
+		redacted     int
+		handler      = mw.NewServerMiddleware(tr, mw.ServerCaptureBody(mw.BodyCapture{
+			ContentTypes: []string{"text/*"},
+			Redact: func(_ string, body []byte) []byte {
+				redacted++
+				return body
+			},
+		}))(http.HandlerFunc(echoHandler))
+	)
+
+	r := httptest.NewRequest("POST", "/", strings.NewReader("hello"))
+	r.Header.Set("Content-Type", "text/plain")
+	r.Header.Set("X-B3-TraceId", "1")
+	r.Header.Set("X-B3-SpanId", "2")
+	r.Header.Set("X-B3-Sampled", "0")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+
+	if want, have := "hello", w.Body.String(); want != have {
+		t.Errorf("unexpected response body, want %s, have %s", want, have)
+	}
+	// bodies of unsampled requests are never buffered
+	if want, have := 0, redacted; want != have {
+		t.Errorf("unexpected number of captured bodies, want %d, have %d", want, have)
+	}
+	if want, have := 0, len(spanRecorder.Flush()); want != have {
+		t.Errorf("unexpected number of spans, want %d, have %d", want, have)
+	}
+}
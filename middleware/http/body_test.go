@@ -0,0 +1,104 @@
+// Copyright 2022 The OpenZipkin Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http_test
+
+import (
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/openzipkin/zipkin-go"
+	mw "github.com/openzipkin/zipkin-go/middleware/http"
+	"github.com/openzipkin/zipkin-go/reporter/recorder"
+)
+
+func TestTransportFinishOnBodyClose(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, strings.Repeat("a", 100))
+	}))
+	defer srv.Close()
+
+	var (
+		spanRecorder = &recorder.ReporterRecorder{}
+		tr, _        = zipkin.NewTracer(spanRecorder)
+		transport, _ = mw.NewTransport(tr, mw.TransportFinishOnBodyClose(time.Minute))
+		client       = &http.Client{Transport: transport}
+	)
+
+	res, err := client.Get(srv.URL)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if want, have := 0, len(spanRecorder.Flush()); want != have {
+		t.Fatalf("expected span to be open until body is consumed, have %d spans", have)
+	}
+
+	if _, err = ioutil.ReadAll(res.Body); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	_ = res.Body.Close()
+
+	spans := spanRecorder.Flush()
+	if want, have := 1, len(spans); want != have {
+		t.Fatalf("unexpected number of spans, want %d, have %d", want, have)
+	}
+	if want, have := "100", spans[0].Tags[mw.TagHTTPResponseBytesRead]; want != have {
+		t.Errorf("unexpected bytes read tag, want %s, have %s", want, have)
+	}
+}
+
+func TestTransportFinishOnBodyTimeout(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, "body")
+	}))
+	defer srv.Close()
+
+	var (
+		spanRecorder = &recorder.ReporterRecorder{}
+		tr, _        = zipkin.NewTracer(spanRecorder)
+		transport, _ = mw.NewTransport(tr, mw.TransportFinishOnBodyClose(10*time.Millisecond))
+		client       = &http.Client{Transport: transport}
+	)
+
+	res, err := client.Get(srv.URL)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer res.Body.Close()
+
+	time.Sleep(50 * time.Millisecond)
+
+	spans := spanRecorder.Flush()
+	if want, have := 1, len(spans); want != have {
+		t.Fatalf("unexpected number of spans, want %d, have %d", want, have)
+	}
+	if want, have := "0", spans[0].Tags[mw.TagHTTPResponseBytesRead]; want != have {
+		t.Errorf("unexpected bytes read tag, want %s, have %s", want, have)
+	}
+	if len(spans[0].Annotations) != 1 || spans[0].Annotations[0].Value != "body timeout" {
+		t.Errorf("expected body timeout annotation, have %+v", spans[0].Annotations)
+	}
+
+	// closing the body afterwards must not finish the span again
+	_ = res.Body.Close()
+	if want, have := 0, len(spanRecorder.Flush()); want != have {
+		t.Errorf("unexpected number of spans, want %d, have %d", want, have)
+	}
+}
@@ -0,0 +1,92 @@
+// Copyright 2022 The OpenZipkin Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/openzipkin/zipkin-go"
+)
+
+// Cancellation tag keys
+const (
+	// TagHTTPCancellation holds "cancelled" if the request context was
+	// cancelled, e.g. by a client disconnect, or "timeout" if its deadline
+	// was exceeded.
+	TagHTTPCancellation = "http.cancellation"
+	// TagHTTPDeadlineRemaining holds the time left before the deadline of the
+	// request context when the request ended.
+	TagHTTPDeadlineRemaining = "http.deadline_remaining"
+)
+
+// Canonical cancellation values
+const (
+	CancellationCancelled = "cancelled"
+	CancellationTimeout   = "timeout"
+)
+
+// ServerCancellationErrors allows one to decide if cancelled or timed out
+// requests are tagged as errors. Enabled by default.
+func ServerCancellationErrors(enabled bool) ServerOption {
+	return func(h *handler) {
+		h.ignoreCancellation = !enabled
+	}
+}
+
+// TransportCancellationErrors allows one to decide if cancelled or timed out
+// requests are tagged as errors. Enabled by default.
+func TransportCancellationErrors(enabled bool) TransportOption {
+	return func(t *transport) {
+		t.ignoreCancellation = !enabled
+	}
+}
+
+// cancellation returns the canonical cancellation value for the context or
+// error, or an empty string if the request was not cancelled.
+func cancellation(ctx context.Context, err error) string {
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		err = ctxErr
+	}
+	switch {
+	case err == nil:
+		return ""
+	case errors.Is(err, context.DeadlineExceeded):
+		return CancellationTimeout
+	case errors.Is(err, context.Canceled):
+		return CancellationCancelled
+	default:
+		return ""
+	}
+}
+
+// tagCancellation tags the cancellation of the request and the time left
+// before the deadline of its context. It returns true if the request was
+// cancelled.
+func tagCancellation(ctx context.Context, err error, sp zipkin.Span, asError bool) bool {
+	if deadline, ok := ctx.Deadline(); ok {
+		sp.Tag(TagHTTPDeadlineRemaining, time.Until(deadline).String())
+	}
+	value := cancellation(ctx, err)
+	if value == "" {
+		return false
+	}
+	sp.Tag(TagHTTPCancellation, value)
+	if asError {
+		zipkin.TagError.Set(sp, value)
+	}
+	return true
+}
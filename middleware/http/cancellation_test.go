@@ -0,0 +1,104 @@
+// Copyright 2022 The OpenZipkin Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http_test
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/openzipkin/zipkin-go"
+	mw "github.com/openzipkin/zipkin-go/middleware/http"
+	"github.com/openzipkin/zipkin-go/reporter/recorder"
+)
+
+func TestHTTPServerCancellation(t *testing.T) {
+	for _, asError := range []bool{true, false} {
+		var (
+			spanRecorder = &recorder.ReporterRecorder{}
+			tr, _        = zipkin.NewTracer(spanRecorder)
+			handler      = mw.NewServerMiddleware(tr, mw.ServerCancellationErrors(asError))(
+				httpHandler(200, nil, &bytes.Buffer{}),
+			)
+		)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil).WithContext(ctx))
+
+		ctx, cancel = context.WithTimeout(context.Background(), time.Nanosecond)
+		<-ctx.Done()
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil).WithContext(ctx))
+		cancel()
+
+		spans := spanRecorder.Flush()
+		if want, have := 2, len(spans); want != have {
+			t.Fatalf("unexpected number of spans, want %d, have %d", want, have)
+		}
+		for i, want := range []string{mw.CancellationCancelled, mw.CancellationTimeout} {
+			if have := spans[i].Tags[mw.TagHTTPCancellation]; want != have {
+				t.Errorf("unexpected cancellation tag, want %s, have %s", want, have)
+			}
+			errTag, isErr := spans[i].Tags[string(zipkin.TagError)]
+			if isErr != asError || (asError && errTag != want) {
+				t.Errorf("unexpected error tag %q (as error: %t)", errTag, asError)
+			}
+		}
+		if remaining, err := time.ParseDuration(spans[1].Tags[mw.TagHTTPDeadlineRemaining]); err != nil || remaining > 0 {
+			t.Errorf("unexpected deadline remaining tag: %q", spans[1].Tags[mw.TagHTTPDeadlineRemaining])
+		}
+	}
+}
+
+func TestTransportCancellation(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	defer srv.Close()
+
+	for _, asError := range []bool{true, false} {
+		var (
+			spanRecorder = &recorder.ReporterRecorder{}
+			tr, _        = zipkin.NewTracer(spanRecorder)
+			transport, _ = mw.NewTransport(tr, mw.TransportCancellationErrors(asError))
+			client       = &http.Client{Transport: transport}
+		)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		req, _ := http.NewRequestWithContext(ctx, "GET", srv.URL, nil)
+		if _, err := client.Do(req); err == nil {
+			t.Fatal("expected error")
+		}
+		cancel()
+
+		spans := spanRecorder.Flush()
+		if want, have := 1, len(spans); want != have {
+			t.Fatalf("unexpected number of spans, want %d, have %d", want, have)
+		}
+		if want, have := mw.CancellationTimeout, spans[0].Tags[mw.TagHTTPCancellation]; want != have {
+			t.Errorf("unexpected cancellation tag, want %s, have %s", want, have)
+		}
+		errTag, isErr := spans[0].Tags[string(zipkin.TagError)]
+		if isErr != asError || (asError && errTag != mw.CancellationTimeout) {
+			t.Errorf("unexpected error tag %q (as error: %t)", errTag, asError)
+		}
+		if _, ok := spans[0].Tags[mw.TagHTTPDeadlineRemaining]; !ok {
+			t.Error("expected deadline remaining tag")
+		}
+	}
+}
@@ -32,9 +32,13 @@ type Client struct {
 	*http.Client
 	tracer           *zipkin.Tracer
 	httpTrace        bool
+	traceMode        TraceMode
+	trackAttempts    bool
 	defaultTags      map[string]string
 	transportOptions []TransportOption
 	remoteEndpoint   *model.Endpoint
+	requestParser    HTTPRequestParser
+	responseParser   HTTPResponseParser
 }
 
 // ClientOption allows optional configuration of Client.
@@ -79,6 +83,22 @@ func WithRemoteEndpoint(remoteEndpoint *model.Endpoint) ClientOption {
 	}
 }
 
+// ClientRequestParser allows one to customize the client span name and tags
+// based on the outgoing request. See TransportRequestParser.
+func ClientRequestParser(p HTTPRequestParser) ClientOption {
+	return func(c *Client) {
+		c.requestParser = p
+	}
+}
+
+// ClientResponseParser allows one to customize client span tags and error
+// classification based on the response. See TransportResponseParser.
+func ClientResponseParser(p HTTPResponseParser) ClientOption {
+	return func(c *Client) {
+		c.responseParser = p
+	}
+}
+
 // NewClient returns an HTTP Client adding Zipkin instrumentation around an
 // embedded standard Go http.Client.
 func NewClient(tracer *zipkin.Tracer, options ...ClientOption) (*Client, error) {
@@ -98,6 +118,18 @@ func NewClient(tracer *zipkin.Tracer, options ...ClientOption) (*Client, error)
 		TransportTrace(c.httpTrace),
 		TransportRemoteEndpoint(c.remoteEndpoint),
 	)
+	if c.httpTrace && c.traceMode != 0 {
+		c.transportOptions = append(c.transportOptions, TransportTraceMode(c.traceMode))
+	}
+	if c.requestParser != nil {
+		c.transportOptions = append(c.transportOptions, TransportRequestParser(c.requestParser))
+	}
+	if c.responseParser != nil {
+		c.transportOptions = append(c.transportOptions, TransportResponseParser(c.responseParser))
+	}
+	if c.trackAttempts {
+		c.Client.CheckRedirect = trackRedirects(c.Client.CheckRedirect)
+	}
 	tr, err := NewTransport(tracer, c.transportOptions...)
 	if err != nil {
 		return nil, err
@@ -120,9 +152,12 @@ func (c *Client) DoWithAppSpan(req *http.Request, name string) (*http.Response,
 	zipkin.TagHTTPMethod.Set(appSpan, req.Method)
 	zipkin.TagHTTPPath.Set(appSpan, req.URL.Path)
 
-	res, err := c.Do(
-		req.WithContext(zipkin.NewContext(req.Context(), appSpan)),
-	)
+	ctx := zipkin.NewContext(req.Context(), appSpan)
+	if c.trackAttempts {
+		ctx = withAttempts(ctx)
+	}
+
+	res, err := c.Do(req.WithContext(ctx))
 	if err != nil {
 		zipkin.TagError.Set(appSpan, err.Error())
 		appSpan.Finish()
@@ -0,0 +1,233 @@
+// Copyright 2022 The OpenZipkin Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/openzipkin/zipkin-go"
+)
+
+// Tags used on connection spans and linked request spans.
+const (
+	TagHTTPConnectionID = "http.connection_id"
+	TagHTTPRequests     = "http.requests"
+	TagTLSVersion       = "tls.version"
+	TagTLSCipher        = "tls.cipher"
+	TagTLSProtocol      = "tls.protocol"
+	TagTLSServerName    = "tls.server_name"
+)
+
+// ConnTracer records the lifecycle of connections accepted by an http.Server
+// as connection spans. Request spans created by the server middleware on a
+// traced connection are tagged with the connection span ID.
+type ConnTracer struct {
+	tracer *zipkin.Tracer
+	mtx    sync.Mutex
+	conns  map[string]*connSpan
+}
+
+type connSpan struct {
+	sp        zipkin.Span
+	id        string
+	requests  uint64
+	tlsConn   *tls.Conn
+	mtx       sync.Mutex
+	handshake zipkin.Span
+	done      chan struct{}
+}
+
+type connSpanKey struct{}
+
+// NewConnTracer returns a new ConnTracer.
+func NewConnTracer(tracer *zipkin.Tracer) *ConnTracer {
+	return &ConnTracer{
+		tracer: tracer,
+		conns:  make(map[string]*connSpan),
+	}
+}
+
+// Instrument hooks the ConnTracer into the ConnContext and ConnState
+// callbacks of the provided http.Server, chaining existing callbacks. The
+// GetConfigForClient callback of the server's TLSConfig is hooked to record
+// TLS handshakes, measured from the ClientHello until the handshake
+// completes. If the server has no TLSConfig, an empty one is set so
+// handshakes of servers started with ListenAndServeTLS are traced as well.
+// A custom TLSConfig must be set before calling Instrument.
+func (c *ConnTracer) Instrument(srv *http.Server) {
+	connContext := srv.ConnContext
+	srv.ConnContext = func(ctx context.Context, conn net.Conn) context.Context {
+		if connContext != nil {
+			ctx = connContext(ctx, conn)
+		}
+		return c.connContext(ctx, conn)
+	}
+
+	connState := srv.ConnState
+	srv.ConnState = func(conn net.Conn, state http.ConnState) {
+		c.connState(conn, state)
+		if connState != nil {
+			connState(conn, state)
+		}
+	}
+
+	if srv.TLSConfig == nil {
+		// ServeTLS clones the config and loads the certificates into the
+		// clone, which keeps our callback.
+		srv.TLSConfig = &tls.Config{}
+	}
+	getConfigForClient := srv.TLSConfig.GetConfigForClient
+	srv.TLSConfig.GetConfigForClient = func(hello *tls.ClientHelloInfo) (*tls.Config, error) {
+		c.clientHello(hello)
+		if getConfigForClient != nil {
+			return getConfigForClient(hello)
+		}
+		return nil, nil
+	}
+}
+
+func connKey(conn net.Conn) string {
+	return conn.LocalAddr().String() + "|" + conn.RemoteAddr().String()
+}
+
+func (c *ConnTracer) connContext(ctx context.Context, conn net.Conn) context.Context {
+	remoteEndpoint, _ := zipkin.NewEndpoint("", conn.RemoteAddr().String())
+	sp := c.tracer.StartSpan("connection", zipkin.RemoteEndpoint(remoteEndpoint))
+	if zipkin.IsNoop(sp) {
+		return ctx
+	}
+
+	cs := &connSpan{sp: sp, id: sp.Context().ID.String()}
+	cs.tlsConn, _ = conn.(*tls.Conn)
+	c.mtx.Lock()
+	c.conns[connKey(conn)] = cs
+	c.mtx.Unlock()
+
+	return context.WithValue(ctx, connSpanKey{}, cs)
+}
+
+func (c *ConnTracer) lookup(conn net.Conn) *connSpan {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	return c.conns[connKey(conn)]
+}
+
+func (c *ConnTracer) clientHello(hello *tls.ClientHelloInfo) {
+	if hello.Conn == nil {
+		return
+	}
+	cs := c.lookup(hello.Conn)
+	if cs == nil || cs.tlsConn == nil {
+		return
+	}
+	cs.mtx.Lock()
+	defer cs.mtx.Unlock()
+	if cs.handshake != nil {
+		return
+	}
+	cs.handshake = c.tracer.StartSpan("tls handshake", zipkin.Parent(cs.sp.Context()))
+	if hello.ServerName != "" {
+		cs.handshake.Tag(TagTLSServerName, hello.ServerName)
+	}
+	cs.done = make(chan struct{})
+	go func() {
+		// The handshake is in progress on the connection's serving goroutine,
+		// so this call waits for it to complete without running it again.
+		cs.finishHandshake(cs.tlsConn.HandshakeContext(context.Background()))
+		close(cs.done)
+	}()
+}
+
+// finishHandshake records the negotiated TLS parameters and finishes the
+// handshake span.
+func (cs *connSpan) finishHandshake(err error) {
+	cs.mtx.Lock()
+	defer cs.mtx.Unlock()
+	if err != nil {
+		zipkin.TagError.Set(cs.handshake, err.Error())
+		cs.handshake.Finish()
+		return
+	}
+	state := cs.tlsConn.ConnectionState()
+	cs.sp.Tag(TagTLSVersion, tlsVersionName(state.Version))
+	cs.sp.Tag(TagTLSCipher, tls.CipherSuiteName(state.CipherSuite))
+	if state.NegotiatedProtocol != "" {
+		cs.sp.Tag(TagTLSProtocol, state.NegotiatedProtocol)
+	}
+	cs.handshake.Finish()
+}
+
+func (c *ConnTracer) connState(conn net.Conn, state http.ConnState) {
+	if state != http.StateHijacked && state != http.StateClosed {
+		return
+	}
+	cs := c.lookup(conn)
+	if cs == nil {
+		return
+	}
+
+	c.mtx.Lock()
+	delete(c.conns, connKey(conn))
+	c.mtx.Unlock()
+
+	cs.mtx.Lock()
+	done := cs.done
+	cs.mtx.Unlock()
+	if done != nil {
+		// make sure the TLS parameters are tagged before finishing
+		<-done
+	}
+
+	if state == http.StateHijacked {
+		cs.sp.Annotate(time.Now(), "hijacked")
+	}
+	cs.sp.Tag(TagHTTPRequests, strconv.FormatUint(atomic.LoadUint64(&cs.requests), 10))
+	cs.sp.Finish()
+}
+
+// requestServed links a request span to the connection span found in the
+// request context, if any.
+func requestServed(ctx context.Context, sp zipkin.Span) {
+	cs, ok := ctx.Value(connSpanKey{}).(*connSpan)
+	if !ok {
+		return
+	}
+	atomic.AddUint64(&cs.requests, 1)
+	if !zipkin.IsNoop(sp) {
+		sp.Tag(TagHTTPConnectionID, cs.id)
+	}
+}
+
+func tlsVersionName(version uint16) string {
+	switch version {
+	case tls.VersionTLS10:
+		return "1.0"
+	case tls.VersionTLS11:
+		return "1.1"
+	case tls.VersionTLS12:
+		return "1.2"
+	case tls.VersionTLS13:
+		return "1.3"
+	default:
+		return "0x" + strconv.FormatUint(uint64(version), 16)
+	}
+}
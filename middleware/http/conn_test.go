@@ -0,0 +1,211 @@
+// Copyright 2022 The OpenZipkin Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"io"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/openzipkin/zipkin-go"
+	mw "github.com/openzipkin/zipkin-go/middleware/http"
+	"github.com/openzipkin/zipkin-go/model"
+	"github.com/openzipkin/zipkin-go/reporter/recorder"
+)
+
+func TestConnTracer(t *testing.T) {
+	var (
+		spanRecorder = &recorder.ReporterRecorder{}
+		tr, _        = zipkin.NewTracer(spanRecorder)
+		handler      = mw.NewServerMiddleware(tr)(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			_, _ = io.WriteString(w, "ok")
+		}))
+		srv = httptest.NewUnstartedServer(handler)
+	)
+	srv.TLS = &tls.Config{}
+	srv.Config.TLSConfig = srv.TLS
+	mw.NewConnTracer(tr).Instrument(srv.Config)
+	srv.StartTLS()
+
+	transport := srv.Client().Transport.(*http.Transport).Clone()
+	client := &http.Client{Transport: transport}
+	for i := 0; i < 2; i++ {
+		res, err := client.Get(srv.URL)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		_, _ = ioutil.ReadAll(res.Body)
+		_ = res.Body.Close()
+	}
+	transport.CloseIdleConnections()
+	srv.Close()
+
+	var connSpan, handshakeSpan *model.SpanModel
+	var requestSpans []model.SpanModel
+	spans := spanRecorder.Flush()
+	for i, span := range spans {
+		switch span.Name {
+		case "connection":
+			connSpan = &spans[i]
+		case "tls handshake":
+			handshakeSpan = &spans[i]
+		default:
+			requestSpans = append(requestSpans, span)
+		}
+	}
+
+	if connSpan == nil || handshakeSpan == nil {
+		t.Fatalf("expected connection and handshake spans, have %+v", spans)
+	}
+	if handshakeSpan.ParentID == nil || *handshakeSpan.ParentID != connSpan.ID {
+		t.Error("expected handshake span to be a child of the connection span")
+	}
+	if _, ok := handshakeSpan.Tags[string(zipkin.TagError)]; ok {
+		t.Errorf("unexpected handshake error: %+v", handshakeSpan.Tags)
+	}
+	if want, have := "2", connSpan.Tags[mw.TagHTTPRequests]; want != have {
+		t.Errorf("unexpected requests tag, want %s, have %s", want, have)
+	}
+	for _, tag := range []string{mw.TagTLSVersion, mw.TagTLSCipher} {
+		if connSpan.Tags[tag] == "" {
+			t.Errorf("expected %s tag, have %+v", tag, connSpan.Tags)
+		}
+	}
+	if want, have := 2, len(requestSpans); want != have {
+		t.Fatalf("unexpected number of request spans, want %d, have %d", want, have)
+	}
+	for _, span := range requestSpans {
+		if want, have := connSpan.ID.String(), span.Tags[mw.TagHTTPConnectionID]; want != have {
+			t.Errorf("unexpected connection id tag, want %s, have %s", want, have)
+		}
+	}
+}
+
+// writeCertificate writes a self-signed certificate and its key to dir.
+func writeCertificate(t *testing.T, dir string) (certFile, keyFile string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	certFile, keyFile = filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	for file, block := range map[string]*pem.Block{
+		certFile: {Type: "CERTIFICATE", Bytes: der},
+		keyFile:  {Type: "PRIVATE KEY", Bytes: keyDER},
+	} {
+		if err = os.WriteFile(file, pem.EncodeToMemory(block), 0600); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	return certFile, keyFile
+}
+
+func TestConnTracerServeTLS(t *testing.T) {
+	var (
+		spanRecorder = &recorder.ReporterRecorder{}
+		tr, _        = zipkin.NewTracer(spanRecorder)
+		closed       = make(chan struct{})
+		idle         = 100 * time.Millisecond
+		srv          = &http.Server{
+			Handler: mw.NewServerMiddleware(tr)(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				_, _ = io.WriteString(w, "ok")
+			})),
+			ConnState: func(_ net.Conn, state http.ConnState) {
+				if state == http.StateClosed {
+					close(closed)
+				}
+			},
+		}
+	)
+	mw.NewConnTracer(tr).Instrument(srv)
+	if srv.TLSConfig == nil {
+		t.Fatal("expected TLSConfig to be set")
+	}
+
+	certFile, keyFile := writeCertificate(t, t.TempDir())
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	go func() { _ = srv.ServeTLS(l, certFile, keyFile) }()
+	defer srv.Close()
+
+	conn, err := tls.Dial("tcp", l.Addr().String(), &tls.Config{InsecureSkipVerify: true, NextProtos: []string{"http/1.1"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// the idle time before the first request is not part of the handshake
+	time.Sleep(idle)
+	_, _ = io.WriteString(conn, "GET / HTTP/1.1\r\nHost: localhost\r\nConnection: close\r\n\r\n")
+	_, _ = ioutil.ReadAll(conn)
+	_ = conn.Close()
+
+	select {
+	case <-closed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for the connection to close")
+	}
+
+	var connSpan, handshakeSpan *model.SpanModel
+	spans := spanRecorder.Flush()
+	for i, span := range spans {
+		switch span.Name {
+		case "connection":
+			connSpan = &spans[i]
+		case "tls handshake":
+			handshakeSpan = &spans[i]
+		}
+	}
+	if connSpan == nil || handshakeSpan == nil {
+		t.Fatalf("expected connection and handshake spans, have %+v", spans)
+	}
+	if _, ok := handshakeSpan.Tags[string(zipkin.TagError)]; ok {
+		t.Errorf("unexpected handshake error: %+v", handshakeSpan.Tags)
+	}
+	if handshakeSpan.Duration >= idle {
+		t.Errorf("expected handshake duration below %s, have %s", idle, handshakeSpan.Duration)
+	}
+	for _, tag := range []string{mw.TagTLSVersion, mw.TagTLSCipher, mw.TagTLSProtocol} {
+		if connSpan.Tags[tag] == "" {
+			t.Errorf("expected %s tag, have %+v", tag, connSpan.Tags)
+		}
+	}
+}
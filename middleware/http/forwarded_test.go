@@ -0,0 +1,52 @@
+// Copyright 2022 The OpenZipkin Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http_test
+
+import (
+	"bytes"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/openzipkin/zipkin-go"
+	"github.com/openzipkin/zipkin-go/middleware"
+	mw "github.com/openzipkin/zipkin-go/middleware/http"
+	"github.com/openzipkin/zipkin-go/reporter/recorder"
+)
+
+func TestHTTPServerRemoteEndpointResolver(t *testing.T) {
+	var (
+		spanRecorder = &recorder.ReporterRecorder{}
+		tr, _        = zipkin.NewTracer(spanRecorder)
+		handler      = mw.NewServerMiddleware(tr, mw.ServerRemoteEndpointResolver(
+			middleware.XForwardedForResolver(middleware.TrustProxyHops(1)),
+		))(httpHandler(200, nil, &bytes.Buffer{}))
+	)
+
+	r := httptest.NewRequest("GET", "/", nil)
+	r.RemoteAddr = "10.0.0.1:1234"
+	r.Header.Set("X-Forwarded-For", "1.2.3.4")
+	handler.ServeHTTP(httptest.NewRecorder(), r)
+
+	spans := spanRecorder.Flush()
+	if want, have := 1, len(spans); want != have {
+		t.Fatalf("unexpected number of spans, want %d, have %d", want, have)
+	}
+	if ep := spans[0].RemoteEndpoint; ep == nil || ep.IPv4.String() != "1.2.3.4" {
+		t.Errorf("unexpected remote endpoint: %+v", ep)
+	}
+	if want, have := "10.0.0.1:1234", spans[0].Tags[middleware.TagProxyAddress]; want != have {
+		t.Errorf("unexpected proxy address tag, want %s, have %s", want, have)
+	}
+}
@@ -0,0 +1,103 @@
+// Copyright 2022 The OpenZipkin Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/openzipkin/zipkin-go"
+)
+
+// Header capture tag key prefixes
+const (
+	TagHTTPRequestHeaderPrefix  = "http.request.header."
+	TagHTTPResponseHeaderPrefix = "http.response.header."
+)
+
+// HeaderRedactor returns the value to record for a captured header value.
+type HeaderRedactor func(value string) string
+
+// RedactHeader is a HeaderRedactor which hides the header value completely.
+func RedactHeader(_ string) string {
+	return "[redacted]"
+}
+
+// HeaderCapture holds the configuration for capturing request and response
+// headers as span tags. Captured headers are tagged using the
+// http.request.header.<name> and http.response.header.<name> keys with the
+// lowercased header name. Multiple values of a header are joined using a comma.
+type HeaderCapture struct {
+	// RequestHeaders holds the allowlist of request headers to capture.
+	RequestHeaders []string
+	// ResponseHeaders holds the allowlist of response headers to capture.
+	ResponseHeaders []string
+	// Redact holds per header redaction rules which are applied before
+	// recording the header value.
+	Redact map[string]HeaderRedactor
+	// MaxValueLength truncates captured values to the provided number of
+	// bytes. If 0 values are not truncated.
+	MaxValueLength int
+}
+
+// headerCapture holds the normalized HeaderCapture configuration.
+type headerCapture struct {
+	request  []string
+	response []string
+	redact   map[string]HeaderRedactor
+	maxLen   int
+}
+
+func newHeaderCapture(hc HeaderCapture) *headerCapture {
+	c := &headerCapture{
+		redact: make(map[string]HeaderRedactor, len(hc.Redact)),
+		maxLen: hc.MaxValueLength,
+	}
+	for _, name := range hc.RequestHeaders {
+		c.request = append(c.request, http.CanonicalHeaderKey(name))
+	}
+	for _, name := range hc.ResponseHeaders {
+		c.response = append(c.response, http.CanonicalHeaderKey(name))
+	}
+	for name, redactor := range hc.Redact {
+		c.redact[http.CanonicalHeaderKey(name)] = redactor
+	}
+	return c
+}
+
+func (c *headerCapture) tagRequest(sp zipkin.Span, h http.Header) {
+	c.tag(sp, TagHTTPRequestHeaderPrefix, c.request, h)
+}
+
+func (c *headerCapture) tagResponse(sp zipkin.Span, h http.Header) {
+	c.tag(sp, TagHTTPResponseHeaderPrefix, c.response, h)
+}
+
+func (c *headerCapture) tag(sp zipkin.Span, prefix string, names []string, h http.Header) {
+	for _, name := range names {
+		values, ok := h[name]
+		if !ok || len(values) == 0 {
+			continue
+		}
+		value := strings.Join(values, ",")
+		if redactor, ok := c.redact[name]; ok && redactor != nil {
+			value = redactor(value)
+		}
+		if c.maxLen > 0 && len(value) > c.maxLen {
+			value = value[:c.maxLen]
+		}
+		sp.Tag(prefix+strings.ToLower(name), value)
+	}
+}
@@ -0,0 +1,88 @@
+// Copyright 2022 The OpenZipkin Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/openzipkin/zipkin-go"
+	mw "github.com/openzipkin/zipkin-go/middleware/http"
+	"github.com/openzipkin/zipkin-go/model"
+	"github.com/openzipkin/zipkin-go/reporter/recorder"
+)
+
+func TestHTTPCaptureHeaders(t *testing.T) {
+	var (
+		spanRecorder = &recorder.ReporterRecorder{}
+		tracer, _    = zipkin.NewTracer(spanRecorder)
+		capture      = mw.HeaderCapture{
+			RequestHeaders:  []string{"x-request-id", "Authorization", "User-Agent", "Missing"},
+			ResponseHeaders: []string{"content-type"},
+			Redact:          map[string]mw.HeaderRedactor{"authorization": mw.RedactHeader},
+			MaxValueLength:  8,
+		}
+	)
+
+	srv := httptest.NewServer(mw.NewServerMiddleware(
+		tracer, mw.ServerCaptureHeaders(capture),
+	)(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		w.Header().Set("X-Secret", "secret")
+	})))
+	defer srv.Close()
+
+	tr, _ := mw.NewTransport(tracer, mw.TransportCaptureHeaders(capture))
+	client := &http.Client{Transport: tr}
+
+	req, _ := http.NewRequest("GET", srv.URL, nil)
+	req.Header.Add("X-Request-Id", "1")
+	req.Header.Add("X-Request-Id", "2")
+	req.Header.Set("Authorization", "Bearer token")
+	req.Header.Set("User-Agent", "a-very-long-user-agent")
+	res, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("unexpected client error: %+v", err)
+	}
+	_ = res.Body.Close()
+
+	spans := spanRecorder.Flush()
+	if want, have := 2, len(spans); want != have {
+		t.Fatalf("unexpected number of spans, want %d, have %d", want, have)
+	}
+
+	for _, span := range spans {
+		want := map[string]string{
+			"http.request.header.x-request-id":  "1,2",
+			"http.request.header.authorization": "[redacte",
+			"http.request.header.user-agent":    "a-very-l",
+			"http.response.header.content-type": "text/pla",
+		}
+		for key, value := range want {
+			if have := span.Tags[key]; have != value {
+				t.Errorf("%s span: unexpected %s tag, want %s, have %s", span.Kind, key, value, have)
+			}
+		}
+		for _, key := range []string{"http.request.header.missing", "http.response.header.x-secret"} {
+			if _, found := span.Tags[key]; found {
+				t.Errorf("%s span: unexpected %s tag", span.Kind, key)
+			}
+		}
+		if span.Kind != model.Server && span.Kind != model.Client {
+			t.Errorf("unexpected span kind: %s", span.Kind)
+		}
+	}
+}
@@ -0,0 +1,146 @@
+// Copyright 2022 The OpenZipkin Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http_test
+
+import (
+	"bufio"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/openzipkin/zipkin-go"
+	mw "github.com/openzipkin/zipkin-go/middleware/http"
+	"github.com/openzipkin/zipkin-go/model"
+	"github.com/openzipkin/zipkin-go/reporter/recorder"
+)
+
+func serveAndFlush(t *testing.T, handler http.Handler, spanRecorder *recorder.ReporterRecorder) model.SpanModel {
+	t.Helper()
+	srv := httptest.NewServer(handler)
+	defer srv.Close()
+
+	res, err := http.Get(srv.URL)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	_, _ = io.Copy(ioutil.Discard, res.Body)
+	_ = res.Body.Close()
+	srv.Close()
+
+	spans := spanRecorder.Flush()
+	if want, have := 1, len(spans); want != have {
+		t.Fatalf("unexpected number of spans, want %d, have %d", want, have)
+	}
+	return spans[0]
+}
+
+func TestHTTPServerReadFromSize(t *testing.T) {
+	var (
+		spanRecorder = &recorder.ReporterRecorder{}
+		tr, _        = zipkin.NewTracer(spanRecorder)
+		body         = strings.Repeat("a", 1000)
+		handler      = mw.NewServerMiddleware(tr, mw.TagResponseSize(true))(
+			http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				if _, ok := w.(io.ReaderFrom); !ok {
+					t.Error("expected io.ReaderFrom to be exposed")
+				}
+				// strings.Reader implements io.WriterTo, hide it so io.Copy
+				// takes the io.ReaderFrom path.
+				_, _ = io.Copy(w, struct{ io.Reader }{strings.NewReader(body)})
+			}),
+		)
+	)
+
+	span := serveAndFlush(t, handler, spanRecorder)
+	if want, have := "1000", span.Tags[string(zipkin.TagHTTPResponseSize)]; want != have {
+		t.Errorf("unexpected response size tag, want %s, have %s", want, have)
+	}
+	if len(span.Annotations) != 1 || span.Annotations[0].Value != "ws" {
+		t.Errorf("expected ws annotation, have %+v", span.Annotations)
+	}
+}
+
+func TestHTTPServerFlushes(t *testing.T) {
+	var (
+		spanRecorder = &recorder.ReporterRecorder{}
+		tr, _        = zipkin.NewTracer(spanRecorder)
+		handler      = mw.NewServerMiddleware(tr)(
+			http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				for i := 0; i < 3; i++ {
+					_, _ = io.WriteString(w, "data: event\n\n")
+					w.(http.Flusher).Flush()
+				}
+			}),
+		)
+	)
+
+	span := serveAndFlush(t, handler, spanRecorder)
+	if want, have := "3", span.Tags[mw.TagHTTPResponseFlushes]; want != have {
+		t.Errorf("unexpected flushes tag, want %s, have %s", want, have)
+	}
+}
+
+func TestHTTPServerKeepHijackedSpans(t *testing.T) {
+	var (
+		spanRecorder = &recorder.ReporterRecorder{}
+		tr, _        = zipkin.NewTracer(spanRecorder)
+		conns        = make(chan net.Conn, 1)
+		handler      = mw.NewServerMiddleware(tr, mw.ServerKeepHijackedSpans(true))(
+			http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				conn, rw, err := w.(http.Hijacker).Hijack()
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+					return
+				}
+				_, _ = rw.WriteString("HTTP/1.1 101 Switching Protocols\r\n\r\n")
+				_ = rw.Flush()
+				conns <- conn
+			}),
+		)
+		srv = httptest.NewServer(handler)
+	)
+	defer srv.Close()
+
+	client, err := net.Dial("tcp", srv.Listener.Addr().String())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer client.Close()
+	_, _ = io.WriteString(client, "GET / HTTP/1.1\r\nHost: test\r\nConnection: Upgrade\r\nUpgrade: test\r\n\r\n")
+	if _, err = bufio.NewReader(client).ReadString('\n'); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	conn := <-conns
+	// give the handler time to return
+	time.Sleep(10 * time.Millisecond)
+	if want, have := 0, len(spanRecorder.Flush()); want != have {
+		t.Fatalf("expected span to be open until connection close, have %d spans", have)
+	}
+
+	_ = conn.Close()
+	spans := spanRecorder.Flush()
+	if want, have := 1, len(spans); want != have {
+		t.Fatalf("unexpected number of spans, want %d, have %d", want, have)
+	}
+	if want, have := "101", spans[0].Tags[string(zipkin.TagHTTPStatusCode)]; want != have {
+		t.Errorf("unexpected status code tag, want %s, have %s", want, have)
+	}
+}
@@ -0,0 +1,55 @@
+// Copyright 2022 The OpenZipkin Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http
+
+import (
+	"net/http"
+
+	"github.com/openzipkin/zipkin-go"
+	"github.com/openzipkin/zipkin-go/middleware"
+)
+
+// ServerCapturePanics will instruct the middleware to recover panics of the
+// wrapped handler, tag the panic value as error, annotate the (truncated)
+// stack trace and finish the span with status code 500. Depending on the
+// provided mode the panic is re-raised or a 500 response is written.
+func ServerCapturePanics(mode middleware.PanicMode) ServerOption {
+	return func(h *handler) {
+		h.capturePanics = true
+		h.panicMode = mode
+	}
+}
+
+// recordPanic records the recovered panic on the server span and responds
+// with status code 500 if the response has not been started.
+func (h handler) recordPanic(sp zipkin.Span, ri *rwInterceptor, recovered interface{}) {
+	middleware.RecordPanic(sp, recovered)
+	if ri.wroteHeader {
+		return
+	}
+	if shouldRepanic(h.panicMode, recovered) {
+		// net/http will abort the response, report it as 500
+		ri.statusCode = 500
+	} else {
+		http.Error(ri, http.StatusText(500), 500)
+	}
+}
+
+// shouldRepanic returns true if the recovered value needs to be re-raised.
+// http.ErrAbortHandler is always re-raised as it is used to abort the
+// response.
+func shouldRepanic(mode middleware.PanicMode, recovered interface{}) bool {
+	return mode == middleware.PanicRepanic || recovered == http.ErrAbortHandler
+}
@@ -0,0 +1,77 @@
+// Copyright 2022 The OpenZipkin Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/openzipkin/zipkin-go"
+	"github.com/openzipkin/zipkin-go/middleware"
+	mw "github.com/openzipkin/zipkin-go/middleware/http"
+	"github.com/openzipkin/zipkin-go/reporter/recorder"
+)
+
+func TestHTTPServerCapturePanics(t *testing.T) {
+	panicHandler := http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		panic("boom")
+	})
+
+	for _, mode := range []middleware.PanicMode{middleware.PanicRepanic, middleware.PanicRecover} {
+		var (
+			spanRecorder = &recorder.ReporterRecorder{}
+			tr, _        = zipkin.NewTracer(spanRecorder)
+			handler      = mw.NewServerMiddleware(tr, mw.ServerCapturePanics(mode))(panicHandler)
+			w            = httptest.NewRecorder()
+			repanicked   interface{}
+		)
+
+		func() {
+			defer func() {
+				repanicked = recover()
+			}()
+			handler.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+		}()
+
+		if mode == middleware.PanicRepanic && repanicked != "boom" {
+			t.Errorf("expected re-panic with boom, have %v", repanicked)
+		}
+		if mode == middleware.PanicRecover {
+			if repanicked != nil {
+				t.Errorf("unexpected panic: %v", repanicked)
+			}
+			if want, have := 500, w.Code; want != have {
+				t.Errorf("unexpected response status code, want %d, have %d", want, have)
+			}
+		}
+
+		spans := spanRecorder.Flush()
+		if want, have := 1, len(spans); want != have {
+			t.Fatalf("unexpected number of spans, want %d, have %d", want, have)
+		}
+		if want, have := "boom", spans[0].Tags[string(zipkin.TagError)]; want != have {
+			t.Errorf("unexpected error tag, want %s, have %s", want, have)
+		}
+		if want, have := "500", spans[0].Tags[string(zipkin.TagHTTPStatusCode)]; want != have {
+			t.Errorf("unexpected status code tag, want %s, have %s", want, have)
+		}
+		// the recovered 500 response adds a ws annotation
+		if len(spans[0].Annotations) == 0 || !strings.HasPrefix(spans[0].Annotations[0].Value, "panic: ") {
+			t.Errorf("expected panic annotation, have %+v", spans[0].Annotations)
+		}
+	}
+}
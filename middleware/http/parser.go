@@ -0,0 +1,141 @@
+// Copyright 2022 The OpenZipkin Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/openzipkin/zipkin-go"
+)
+
+// HTTPRequestParser customizes a span based on the HTTP request. It is called
+// by server middleware and transport after the span has been started and can
+// be used to set the span name and add tags.
+type HTTPRequestParser interface {
+	ParseRequest(r *http.Request, sp zipkin.Span)
+}
+
+// HTTPResponseParser customizes a span based on the HTTP response or the
+// error which prevented one. It is called by server middleware and transport
+// right before the span is finished and can be used to add tags and classify
+// errors.
+type HTTPResponseParser interface {
+	ParseResponse(res HTTPResponse, sp zipkin.Span)
+}
+
+// HTTPRequestParserFunc allows the use of ordinary functions as
+// HTTPRequestParser.
+type HTTPRequestParserFunc func(r *http.Request, sp zipkin.Span)
+
+// ParseRequest calls f(r, sp).
+func (f HTTPRequestParserFunc) ParseRequest(r *http.Request, sp zipkin.Span) {
+	f(r, sp)
+}
+
+// HTTPResponseParserFunc allows the use of ordinary functions as
+// HTTPResponseParser.
+type HTTPResponseParserFunc func(res HTTPResponse, sp zipkin.Span)
+
+// ParseResponse calls f(res, sp).
+func (f HTTPResponseParserFunc) ParseResponse(res HTTPResponse, sp zipkin.Span) {
+	f(res, sp)
+}
+
+// HTTPResponse holds the details available to HTTPResponseParser.
+type HTTPResponse struct {
+	// Request holds the request as handled by the server or sent by the
+	// transport.
+	Request *http.Request
+	// Response holds the response received by the transport. It is nil for
+	// server spans and failed client requests.
+	Response *http.Response
+	// StatusCode holds the response status code. It is 0 if no response was
+	// received.
+	StatusCode int
+	// Size holds the response size in bytes. It is -1 if unknown or, for
+	// server spans, if not tracked. See TagResponseSize.
+	Size int64
+	// Route holds the route which matched the request. It is only available
+	// for server spans. See ServerRouteExtractor.
+	Route string
+	// Err holds the error returned by the transport.
+	Err error
+}
+
+// DefaultServerRequestParser is the HTTPRequestParser used by the server
+// middleware by default. It tags the request method, path and size.
+var DefaultServerRequestParser HTTPRequestParser = HTTPRequestParserFunc(
+	func(r *http.Request, sp zipkin.Span) {
+		zipkin.TagHTTPMethod.Set(sp, r.Method)
+		zipkin.TagHTTPPath.Set(sp, r.URL.Path)
+		if r.ContentLength > 0 {
+			zipkin.TagHTTPRequestSize.Set(sp, strconv.FormatInt(r.ContentLength, 10))
+		}
+	},
+)
+
+// DefaultClientRequestParser is the HTTPRequestParser used by the transport
+// by default. It names the span after the request scheme and method and tags
+// the request method and path.
+var DefaultClientRequestParser HTTPRequestParser = HTTPRequestParserFunc(
+	func(r *http.Request, sp zipkin.Span) {
+		sp.SetName(clientSpanName(r))
+		zipkin.TagHTTPMethod.Set(sp, r.Method)
+		zipkin.TagHTTPPath.Set(sp, r.URL.Path)
+	},
+)
+
+// DefaultResponseParser returns the HTTPResponseParser used by the server
+// middleware and transport by default. It tags the response size if known and
+// the status code if not 2xx. Errors and status codes >399 are handed to the
+// provided ErrHandler.
+func DefaultResponseParser(eh ErrHandler) HTTPResponseParser {
+	if eh == nil {
+		eh = defaultErrHandler
+	}
+	return HTTPResponseParserFunc(func(res HTTPResponse, sp zipkin.Span) {
+		if res.Err != nil {
+			eh(sp, res.Err, 0)
+			return
+		}
+		if res.Size > 0 {
+			zipkin.TagHTTPResponseSize.Set(sp, strconv.FormatInt(res.Size, 10))
+		}
+		if res.StatusCode < 200 || res.StatusCode > 299 {
+			zipkin.TagHTTPStatusCode.Set(sp, strconv.Itoa(res.StatusCode))
+			if res.StatusCode > 399 {
+				eh(sp, nil, res.StatusCode)
+			}
+		}
+	})
+}
+
+// namedSpan records if the span was named by a parser.
+type namedSpan struct {
+	zipkin.Span
+	named bool
+}
+
+// SetName implements zipkin.Span.
+func (s *namedSpan) SetName(name string) {
+	s.named = true
+	s.Span.SetName(name)
+}
+
+// clientSpanName returns the default client span name.
+func clientSpanName(r *http.Request) string {
+	return r.URL.Scheme + "/" + r.Method
+}
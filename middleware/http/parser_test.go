@@ -0,0 +1,124 @@
+// Copyright 2022 The OpenZipkin Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http_test
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/openzipkin/zipkin-go"
+	mw "github.com/openzipkin/zipkin-go/middleware/http"
+	"github.com/openzipkin/zipkin-go/reporter/recorder"
+)
+
+func TestHTTPServerParsers(t *testing.T) {
+	var (
+		spanRecorder = &recorder.ReporterRecorder{}
+		tr, _        = zipkin.NewTracer(spanRecorder)
+	)
+
+	handler := mw.NewServerMiddleware(
+		tr,
+		mw.ServerRequestParser(mw.HTTPRequestParserFunc(func(r *http.Request, sp zipkin.Span) {
+			mw.DefaultServerRequestParser.ParseRequest(r, sp)
+			sp.SetName("custom " + r.Method)
+			sp.Tag("user-agent", r.UserAgent())
+		})),
+		mw.ServerResponseParser(mw.HTTPResponseParserFunc(func(res mw.HTTPResponse, sp zipkin.Span) {
+			// only classify server errors as errors
+			zipkin.TagHTTPStatusCode.Set(sp, strconv.Itoa(res.StatusCode))
+			if res.StatusCode > 499 {
+				zipkin.TagError.Set(sp, "server error")
+			}
+		})),
+	)(httpHandler(404, nil, &bytes.Buffer{}))
+
+	req := httptest.NewRequest("GET", "/missing", nil)
+	req.Header.Set("User-Agent", "parser-test")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	spans := spanRecorder.Flush()
+	if want, have := 1, len(spans); want != have {
+		t.Fatalf("unexpected number of spans, want %d, have %d", want, have)
+	}
+
+	if want, have := "custom GET", spans[0].Name; want != have {
+		t.Errorf("unexpected span name, want %s, have %s", want, have)
+	}
+	if want, have := "parser-test", spans[0].Tags["user-agent"]; want != have {
+		t.Errorf("unexpected user-agent tag, want %s, have %s", want, have)
+	}
+	if want, have := "/missing", spans[0].Tags[string(zipkin.TagHTTPPath)]; want != have {
+		t.Errorf("unexpected http.path tag, want %s, have %s", want, have)
+	}
+	if want, have := "404", spans[0].Tags[string(zipkin.TagHTTPStatusCode)]; want != have {
+		t.Errorf("unexpected status code tag, want %s, have %s", want, have)
+	}
+	if _, found := spans[0].Tags[string(zipkin.TagError)]; found {
+		t.Errorf("unexpected error tag")
+	}
+}
+
+func TestHTTPTransportParsers(t *testing.T) {
+	var (
+		spanRecorder = &recorder.ReporterRecorder{}
+		tracer, _    = zipkin.NewTracer(spanRecorder)
+		srv          = httptest.NewServer(httpHandler(503, nil, &bytes.Buffer{}))
+	)
+	defer srv.Close()
+
+	client, err := mw.NewClient(
+		tracer,
+		mw.ClientRequestParser(mw.HTTPRequestParserFunc(func(r *http.Request, sp zipkin.Span) {
+			sp.SetName("backend " + r.Method)
+		})),
+		mw.ClientResponseParser(mw.HTTPResponseParserFunc(func(res mw.HTTPResponse, sp zipkin.Span) {
+			if res.Response == nil {
+				t.Errorf("expected response to be available")
+			}
+			sp.Tag("status", strconv.Itoa(res.StatusCode))
+		})),
+	)
+	if err != nil {
+		t.Fatalf("unable to create client: %+v", err)
+	}
+
+	req, _ := http.NewRequest("GET", srv.URL, nil)
+	res, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("unexpected client error: %+v", err)
+	}
+	_ = res.Body.Close()
+
+	spans := spanRecorder.Flush()
+	if want, have := 1, len(spans); want != have {
+		t.Fatalf("unexpected number of spans, want %d, have %d", want, have)
+	}
+
+	if want, have := "backend GET", spans[0].Name; want != have {
+		t.Errorf("unexpected span name, want %s, have %s", want, have)
+	}
+	if want, have := "503", spans[0].Tags["status"]; want != have {
+		t.Errorf("unexpected status tag, want %s, have %s", want, have)
+	}
+	for _, tag := range []zipkin.Tag{zipkin.TagHTTPMethod, zipkin.TagHTTPStatusCode, zipkin.TagError} {
+		if _, found := spans[0].Tags[string(tag)]; found {
+			t.Errorf("unexpected %s tag", tag)
+		}
+	}
+}
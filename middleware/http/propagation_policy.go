@@ -0,0 +1,72 @@
+// Copyright 2022 The OpenZipkin Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http
+
+import (
+	"net/http"
+	"strings"
+)
+
+// TagHTTPPropagated records if the trace context was injected into the
+// outgoing request.
+const TagHTTPPropagated = "http.propagated"
+
+// PropagationPolicy decides if the trace context and baggage can be injected
+// into an outgoing request.
+type PropagationPolicy func(r *http.Request) bool
+
+// PropagateToHosts returns a PropagationPolicy which only injects the trace
+// context into requests for the provided hosts. A host starting with a dot
+// matches all of its subdomains, e.g. ".svc.local" matches "api.svc.local".
+func PropagateToHosts(hosts ...string) PropagationPolicy {
+	return func(r *http.Request) bool {
+		return matchHost(hosts, r.URL.Hostname())
+	}
+}
+
+// DontPropagateToHosts returns a PropagationPolicy which injects the trace
+// context into all requests except for the ones for the provided hosts. Hosts
+// are matched as in PropagateToHosts.
+func DontPropagateToHosts(hosts ...string) PropagationPolicy {
+	return func(r *http.Request) bool {
+		return !matchHost(hosts, r.URL.Hostname())
+	}
+}
+
+// TransportPropagationPolicy allows one to restrict injection of the trace
+// context and baggage to trusted destinations. Client spans are still created
+// for requests which are not propagated to.
+func TransportPropagationPolicy(p PropagationPolicy) TransportOption {
+	return func(t *transport) {
+		t.propagationPolicy = p
+	}
+}
+
+func matchHost(hosts []string, host string) bool {
+	host = strings.ToLower(host)
+	for _, h := range hosts {
+		h = strings.ToLower(h)
+		if strings.HasPrefix(h, ".") {
+			if strings.HasSuffix(host, h) || host == h[1:] {
+				return true
+			}
+			continue
+		}
+		if host == h {
+			return true
+		}
+	}
+	return false
+}
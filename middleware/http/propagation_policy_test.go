@@ -0,0 +1,89 @@
+// Copyright 2022 The OpenZipkin Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/openzipkin/zipkin-go"
+	mw "github.com/openzipkin/zipkin-go/middleware/http"
+	"github.com/openzipkin/zipkin-go/propagation/b3"
+	"github.com/openzipkin/zipkin-go/reporter/recorder"
+)
+
+func TestTransportPropagationPolicy(t *testing.T) {
+	var traceHeader string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceHeader = r.Header.Get(b3.TraceID)
+	}))
+	defer srv.Close()
+
+	testCases := []struct {
+		policy    mw.PropagationPolicy
+		propagate bool
+	}{
+		{mw.PropagateToHosts("127.0.0.1"), true},
+		{mw.PropagateToHosts("example.com", ".svc.local"), false},
+		{mw.DontPropagateToHosts("127.0.0.1"), false},
+		{mw.DontPropagateToHosts("example.com"), true},
+		{func(*http.Request) bool { return false }, false},
+	}
+
+	for i, tc := range testCases {
+		var (
+			spanRecorder = &recorder.ReporterRecorder{}
+			tr, _        = zipkin.NewTracer(spanRecorder)
+			transport, _ = mw.NewTransport(tr, mw.TransportPropagationPolicy(tc.policy))
+			client       = &http.Client{Transport: transport}
+		)
+		traceHeader = ""
+
+		res, err := client.Get(srv.URL)
+		if err != nil {
+			t.Fatalf("[%d] unexpected error: %v", i, err)
+		}
+		_ = res.Body.Close()
+
+		if want, have := tc.propagate, traceHeader != ""; want != have {
+			t.Errorf("[%d] unexpected propagation, want %t, have %t", i, want, have)
+		}
+
+		spans := spanRecorder.Flush()
+		if want, have := 1, len(spans); want != have {
+			t.Fatalf("[%d] unexpected number of spans, want %d, have %d", i, want, have)
+		}
+		if want, have := tc.propagate, spans[0].Tags[mw.TagHTTPPropagated] == "true"; want != have {
+			t.Errorf("[%d] unexpected propagated tag: %q", i, spans[0].Tags[mw.TagHTTPPropagated])
+		}
+	}
+}
+
+func TestPropagateToHostsSubdomains(t *testing.T) {
+	policy := mw.PropagateToHosts(".svc.local")
+	for host, want := range map[string]bool{
+		"http://api.svc.local/":      true,
+		"http://svc.local:8080/":     true,
+		"http://API.SVC.LOCAL/":      true,
+		"http://evilsvc.local/":      false,
+		"http://api.svc.local.evil/": false,
+	} {
+		r := httptest.NewRequest("GET", host, nil)
+		if have := policy(r); want != have {
+			t.Errorf("%s: want %t, have %t", host, want, have)
+		}
+	}
+}
@@ -0,0 +1,84 @@
+// Copyright 2022 The OpenZipkin Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http
+
+import (
+	"crypto/tls"
+	"net/http"
+	"strconv"
+
+	"github.com/openzipkin/zipkin-go"
+)
+
+// Protocol tag keys
+const (
+	TagHTTPFlavor                  = "http.flavor"
+	TagHTTPScheme                  = "http.scheme"
+	TagHTTPHost                    = "http.host"
+	TagHTTPRequestContentEncoding  = "http.request.content_encoding"
+	TagHTTPResponseContentEncoding = "http.response.content_encoding"
+	TagTLSClientSubject            = "tls.client.subject"
+)
+
+// ServerProtocolTags allows one to tag server spans with the HTTP protocol
+// version, scheme and host, the negotiated TLS version, cipher suite, server
+// name and client certificate subject, and the request and response content
+// encodings.
+func ServerProtocolTags(enable bool) ServerOption {
+	return func(h *handler) {
+		h.protocolTags = enable
+	}
+}
+
+// tagProtocol tags the protocol details of the incoming request.
+func tagProtocol(r *http.Request, sp zipkin.Span) {
+	flavor := strconv.Itoa(r.ProtoMajor)
+	if r.ProtoMajor < 2 {
+		flavor += "." + strconv.Itoa(r.ProtoMinor)
+	}
+	sp.Tag(TagHTTPFlavor, flavor)
+	if r.TLS != nil {
+		sp.Tag(TagHTTPScheme, "https")
+	} else {
+		sp.Tag(TagHTTPScheme, "http")
+	}
+	if r.Host != "" {
+		sp.Tag(TagHTTPHost, r.Host)
+	}
+	if encoding := r.Header.Get("Content-Encoding"); encoding != "" {
+		sp.Tag(TagHTTPRequestContentEncoding, encoding)
+	}
+	if r.TLS != nil {
+		tagTLSState(r.TLS, sp)
+	}
+}
+
+// tagResponseEncoding tags the content encoding of the response.
+func tagResponseEncoding(header http.Header, sp zipkin.Span) {
+	if encoding := header.Get("Content-Encoding"); encoding != "" {
+		sp.Tag(TagHTTPResponseContentEncoding, encoding)
+	}
+}
+
+func tagTLSState(state *tls.ConnectionState, sp zipkin.Span) {
+	sp.Tag(TagTLSVersion, tlsVersionName(state.Version))
+	sp.Tag(TagTLSCipher, tls.CipherSuiteName(state.CipherSuite))
+	if state.ServerName != "" {
+		sp.Tag(TagTLSServerName, state.ServerName)
+	}
+	if len(state.PeerCertificates) > 0 {
+		sp.Tag(TagTLSClientSubject, state.PeerCertificates[0].Subject.String())
+	}
+}
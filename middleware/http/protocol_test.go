@@ -0,0 +1,95 @@
+// Copyright 2022 The OpenZipkin Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http_test
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/openzipkin/zipkin-go"
+	mw "github.com/openzipkin/zipkin-go/middleware/http"
+	"github.com/openzipkin/zipkin-go/reporter/recorder"
+)
+
+func TestHTTPServerProtocolTags(t *testing.T) {
+	var (
+		spanRecorder = &recorder.ReporterRecorder{}
+		tr, _        = zipkin.NewTracer(spanRecorder)
+		headers      = http.Header{"Content-Encoding": []string{"gzip"}}
+		handler      = mw.NewServerMiddleware(tr, mw.ServerProtocolTags(true))(
+			httpHandler(200, headers, &bytes.Buffer{}),
+		)
+	)
+
+	r := httptest.NewRequest("POST", "https://api.example.com/", nil)
+	r.Proto, r.ProtoMajor, r.ProtoMinor = "HTTP/2.0", 2, 0
+	r.Header.Set("Content-Encoding", "br")
+	r.TLS = &tls.ConnectionState{
+		Version:     tls.VersionTLS13,
+		CipherSuite: tls.TLS_AES_128_GCM_SHA256,
+		ServerName:  "api.example.com",
+		PeerCertificates: []*x509.Certificate{
+			{Subject: pkix.Name{CommonName: "client"}},
+		},
+	}
+	handler.ServeHTTP(httptest.NewRecorder(), r)
+
+	spans := spanRecorder.Flush()
+	if want, have := 1, len(spans); want != have {
+		t.Fatalf("unexpected number of spans, want %d, have %d", want, have)
+	}
+	for key, want := range map[string]string{
+		mw.TagHTTPFlavor:                 "2",
+		mw.TagHTTPScheme:                 "https",
+		mw.TagHTTPHost:                   "api.example.com",
+		mw.TagHTTPRequestContentEncoding: "br",
+		mw.TagTLSVersion:                 "1.3",
+		mw.TagTLSCipher:                  "TLS_AES_128_GCM_SHA256",
+		mw.TagTLSServerName:              "api.example.com",
+		mw.TagTLSClientSubject:           "CN=client",
+	} {
+		if have := spans[0].Tags[key]; want != have {
+			t.Errorf("unexpected %s tag, want %s, have %s", key, want, have)
+		}
+	}
+}
+
+func TestHTTPServerProtocolTagsPlain(t *testing.T) {
+	var (
+		spanRecorder = &recorder.ReporterRecorder{}
+		tr, _        = zipkin.NewTracer(spanRecorder)
+		handler      = mw.NewServerMiddleware(tr, mw.ServerProtocolTags(true))(
+			httpHandler(200, nil, &bytes.Buffer{}),
+		)
+	)
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+
+	spans := spanRecorder.Flush()
+	if want, have := "1.1", spans[0].Tags[mw.TagHTTPFlavor]; want != have {
+		t.Errorf("unexpected flavor tag, want %s, have %s", want, have)
+	}
+	if want, have := "http", spans[0].Tags[mw.TagHTTPScheme]; want != have {
+		t.Errorf("unexpected scheme tag, want %s, have %s", want, have)
+	}
+	if _, ok := spans[0].Tags[mw.TagTLSVersion]; ok {
+		t.Errorf("unexpected TLS tags on plain request: %+v", spans[0].Tags)
+	}
+}
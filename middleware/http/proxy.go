@@ -0,0 +1,175 @@
+// Copyright 2022 The OpenZipkin Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http
+
+import (
+	"log"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"strconv"
+
+	"github.com/openzipkin/zipkin-go"
+	"github.com/openzipkin/zipkin-go/middleware"
+	"github.com/openzipkin/zipkin-go/model"
+	"github.com/openzipkin/zipkin-go/propagation/b3"
+)
+
+// Tags used on reverse proxy server spans.
+const (
+	TagProxyUpstreamAddress    = "proxy.upstream.address"
+	TagProxyUpstreamStatusCode = "proxy.upstream.status_code"
+	TagProxyError              = "proxy.error"
+)
+
+type reverseProxy struct {
+	serverOptions    []ServerOption
+	transportOptions []TransportOption
+	errorHandler     func(http.ResponseWriter, *http.Request, error)
+	modifyResponse   func(*http.Response) error
+}
+
+// ReverseProxyOption allows optional configuration of the reverse proxy.
+type ReverseProxyOption func(*reverseProxy)
+
+// ProxyServerOptions passes optional configuration to the server middleware
+// handling the inbound requests.
+func ProxyServerOptions(options ...ServerOption) ReverseProxyOption {
+	return func(p *reverseProxy) {
+		p.serverOptions = options
+	}
+}
+
+// ProxyTransportOptions passes optional configuration to the transport used
+// for the upstream requests.
+func ProxyTransportOptions(options ...TransportOption) ReverseProxyOption {
+	return func(p *reverseProxy) {
+		p.transportOptions = options
+	}
+}
+
+// ProxyErrorHandler sets the httputil.ReverseProxy ErrorHandler. The error is
+// tagged on the server span before the handler is called.
+func ProxyErrorHandler(h func(http.ResponseWriter, *http.Request, error)) ReverseProxyOption {
+	return func(p *reverseProxy) {
+		p.errorHandler = h
+	}
+}
+
+// ProxyModifyResponse sets the httputil.ReverseProxy ModifyResponse function.
+func ProxyModifyResponse(f func(*http.Response) error) ReverseProxyOption {
+	return func(p *reverseProxy) {
+		p.modifyResponse = f
+	}
+}
+
+// upstreamEndpoint returns the remote endpoint of the target without
+// resolving its host name, as the upstream may resolve to other addresses
+// over time. Only IP literals are recorded as address.
+func upstreamEndpoint(target *url.URL) *model.Endpoint {
+	host := target.Hostname()
+	if host == "" {
+		return nil
+	}
+	ep := &model.Endpoint{}
+	if ip := net.ParseIP(host); ip == nil {
+		ep.ServiceName = host
+	} else if ip4 := ip.To4(); ip4 != nil {
+		ep.IPv4 = ip4
+	} else {
+		ep.IPv6 = ip
+	}
+	if port, err := strconv.ParseUint(target.Port(), 10, 16); err == nil {
+		ep.Port = uint16(port)
+	}
+	return ep
+}
+
+// NewReverseProxy returns an instrumented httputil.ReverseProxy routing
+// requests to the provided target. Inbound requests are recorded as server
+// spans and the upstream requests as child client spans. Incoming trace
+// context and baggage headers are replaced by the ones of the client span.
+func NewReverseProxy(tracer *zipkin.Tracer, target *url.URL, options ...ReverseProxyOption) (http.Handler, error) {
+	if tracer == nil {
+		return nil, ErrValidTracerRequired
+	}
+
+	p := &reverseProxy{}
+	for _, option := range options {
+		option(p)
+	}
+
+	transportOptions := p.transportOptions
+	if ep := upstreamEndpoint(target); ep != nil {
+		// allow an explicitly configured remote endpoint to take precedence
+		transportOptions = append([]TransportOption{TransportRemoteEndpoint(ep)}, transportOptions...)
+	}
+	transport, err := NewTransport(tracer, transportOptions...)
+	if err != nil {
+		return nil, err
+	}
+
+	rp := httputil.NewSingleHostReverseProxy(target)
+	director := rp.Director
+	rp.Director = func(req *http.Request) {
+		director(req)
+		stripTraceHeaders(req)
+		if sp := zipkin.SpanFromContext(req.Context()); sp != nil {
+			sp.Tag(TagProxyUpstreamAddress, req.URL.Host)
+		}
+	}
+	rp.Transport = transport
+	rp.ModifyResponse = func(res *http.Response) error {
+		if sp := zipkin.SpanFromContext(res.Request.Context()); sp != nil {
+			sp.Tag(TagProxyUpstreamStatusCode, strconv.Itoa(res.StatusCode))
+		}
+		if p.modifyResponse != nil {
+			return p.modifyResponse(res)
+		}
+		return nil
+	}
+	rp.ErrorHandler = func(w http.ResponseWriter, req *http.Request, err error) {
+		if sp := zipkin.SpanFromContext(req.Context()); sp != nil {
+			sp.Tag(TagProxyError, err.Error())
+		}
+		if p.errorHandler != nil {
+			p.errorHandler(w, req, err)
+			return
+		}
+		log.Printf("http: proxy error: %v", err)
+		w.WriteHeader(http.StatusBadGateway)
+	}
+
+	return NewServerMiddleware(tracer, p.serverOptions...)(rp), nil
+}
+
+// stripTraceHeaders removes the inbound trace context and baggage headers
+// copied by the reverse proxy so they can't conflict with the ones injected
+// by the transport.
+func stripTraceHeaders(req *http.Request) {
+	for _, header := range []string{
+		b3.Context, b3.TraceID, b3.SpanID, b3.ParentSpanID, b3.Sampled, b3.Flags,
+	} {
+		req.Header.Del(header)
+	}
+	sp := zipkin.SpanFromContext(req.Context())
+	if sp == nil || sp.Context().Baggage == nil {
+		return
+	}
+	middleware.InjectBaggage(sp.Context().Baggage, func(key string, _ []string) {
+		req.Header.Del(key)
+	})
+}
@@ -0,0 +1,188 @@
+// Copyright 2022 The OpenZipkin Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http_test
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/openzipkin/zipkin-go"
+	mw "github.com/openzipkin/zipkin-go/middleware/http"
+	"github.com/openzipkin/zipkin-go/model"
+	"github.com/openzipkin/zipkin-go/propagation/b3"
+	"github.com/openzipkin/zipkin-go/propagation/baggage"
+	"github.com/openzipkin/zipkin-go/reporter/recorder"
+)
+
+func TestReverseProxy(t *testing.T) {
+	var upstreamHeaders http.Header
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		upstreamHeaders = r.Header.Clone()
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer upstream.Close()
+	target, _ := url.Parse(upstream.URL)
+
+	var (
+		spanRecorder = &recorder.ReporterRecorder{}
+		tr, _        = zipkin.NewTracer(spanRecorder)
+		proxy, err   = mw.NewReverseProxy(
+			tr, target, mw.ProxyServerOptions(mw.EnableBaggage(baggage.New(reqID))),
+		)
+	)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	req := httptest.NewRequest("GET", "/resource", nil)
+	req.Header.Set(b3.TraceID, foreignTraceID)
+	req.Header.Set(b3.SpanID, foreignSpanID)
+	req.Header.Set(b3.Sampled, "1")
+	req.Header.Set(reqID, reqIDValue)
+	w := httptest.NewRecorder()
+	proxy.ServeHTTP(w, req)
+
+	if want, have := http.StatusAccepted, w.Code; want != have {
+		t.Fatalf("unexpected status code, want %d, have %d", want, have)
+	}
+
+	spans := spanRecorder.Flush()
+	if want, have := 2, len(spans); want != have {
+		t.Fatalf("unexpected number of spans, want %d, have %d", want, have)
+	}
+	var serverSpan, clientSpan model.SpanModel
+	for _, span := range spans {
+		switch span.Kind {
+		case model.Server:
+			serverSpan = span
+		case model.Client:
+			clientSpan = span
+		}
+	}
+
+	if clientSpan.ParentID == nil || *clientSpan.ParentID != serverSpan.ID {
+		t.Errorf("expected client span to be a child of the server span")
+	}
+	if want, have := target.Host, serverSpan.Tags[mw.TagProxyUpstreamAddress]; want != have {
+		t.Errorf("unexpected upstream address tag, want %s, have %s", want, have)
+	}
+	if want, have := "202", serverSpan.Tags[mw.TagProxyUpstreamStatusCode]; want != have {
+		t.Errorf("unexpected upstream status tag, want %s, have %s", want, have)
+	}
+	if clientSpan.RemoteEndpoint == nil || clientSpan.RemoteEndpoint.Port == 0 {
+		t.Errorf("expected upstream remote endpoint, have %+v", clientSpan.RemoteEndpoint)
+	}
+
+	if want, have := clientSpan.ID.String(), upstreamHeaders.Get(b3.SpanID); want != have {
+		t.Errorf("unexpected upstream span id, want %s, have %s", want, have)
+	}
+	if want, have := foreignTraceID, upstreamHeaders.Get(b3.TraceID); want != have {
+		t.Errorf("unexpected upstream trace id, want %s, have %s", want, have)
+	}
+	if want, have := []string{reqIDValue}, upstreamHeaders.Values(reqID); len(have) != 1 || want[0] != have[0] {
+		t.Errorf("unexpected upstream baggage, want %v, have %v", want, have)
+	}
+}
+
+func TestReverseProxyErrorHandler(t *testing.T) {
+	upstream := httptest.NewServer(http.NotFoundHandler())
+	target, _ := url.Parse(upstream.URL)
+	upstream.Close()
+
+	var (
+		spanRecorder = &recorder.ReporterRecorder{}
+		tr, _        = zipkin.NewTracer(spanRecorder)
+		handled      bool
+		proxy, _     = mw.NewReverseProxy(tr, target, mw.ProxyErrorHandler(
+			func(w http.ResponseWriter, _ *http.Request, _ error) {
+				handled = true
+				w.WriteHeader(http.StatusServiceUnavailable)
+			},
+		))
+		w = httptest.NewRecorder()
+	)
+
+	proxy.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+
+	if !handled {
+		t.Error("expected error handler to be called")
+	}
+	if want, have := http.StatusServiceUnavailable, w.Code; want != have {
+		t.Errorf("unexpected status code, want %d, have %d", want, have)
+	}
+
+	for _, span := range spanRecorder.Flush() {
+		if span.Kind != model.Server {
+			continue
+		}
+		if span.Tags[mw.TagProxyError] == "" {
+			t.Errorf("expected proxy error tag, have %+v", span.Tags)
+		}
+		if want, have := "503", span.Tags[string(zipkin.TagError)]; want != have {
+			t.Errorf("unexpected error tag, want %s, have %s", want, have)
+		}
+	}
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestReverseProxyUpstreamEndpoint(t *testing.T) {
+	for _, c := range []struct {
+		target string
+		want   model.Endpoint
+	}{
+		{target: "http://upstream.invalid:8080", want: model.Endpoint{ServiceName: "upstream.invalid", Port: 8080}},
+		{target: "http://upstream.invalid", want: model.Endpoint{ServiceName: "upstream.invalid"}},
+		{target: "http://10.0.0.1:8080", want: model.Endpoint{IPv4: net.ParseIP("10.0.0.1").To4(), Port: 8080}},
+		{target: "http://[2001:db8::1]:8080", want: model.Endpoint{IPv6: net.ParseIP("2001:db8::1"), Port: 8080}},
+	} {
+		target, _ := url.Parse(c.target)
+		var (
+			spanRecorder = &recorder.ReporterRecorder{}
+			tr, _        = zipkin.NewTracer(spanRecorder)
+			// the upstream host name is never resolved
+			proxy, err = mw.NewReverseProxy(tr, target, mw.ProxyTransportOptions(
+				mw.RoundTripper(roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+					return &http.Response{StatusCode: http.StatusOK, Header: http.Header{}, Body: http.NoBody, Request: req}, nil
+				})),
+			))
+		)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		proxy.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+
+		for _, span := range spanRecorder.Flush() {
+			if span.Kind != model.Client {
+				continue
+			}
+			if span.RemoteEndpoint == nil {
+				t.Fatalf("%s: expected remote endpoint", c.target)
+			}
+			if want, have := c.want, *span.RemoteEndpoint; want.ServiceName != have.ServiceName ||
+				want.Port != have.Port || !want.IPv4.Equal(have.IPv4) || !want.IPv6.Equal(have.IPv6) {
+				t.Errorf("%s: unexpected remote endpoint, want %+v, have %+v", c.target, want, have)
+			}
+		}
+	}
+}
@@ -0,0 +1,118 @@
+// Copyright 2022 The OpenZipkin Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/openzipkin/zipkin-go"
+)
+
+// TagHTTPQueueTime holds the time a request spent queued in front of the
+// service, as reported by the proxy.
+const TagHTTPQueueTime = "http.queue_time"
+
+// DefaultMaxQueueTime is used by ServerQueueTime if no MaxQueueTime is set.
+const DefaultMaxQueueTime = time.Minute
+
+// QueueTime configures recording of the time requests spent queued in a
+// proxy in front of the service, based on the X-Request-Start or
+// X-Queue-Start headers. Supported formats are "t=<timestamp>" and
+// "<timestamp>" in seconds (optionally fractional), milliseconds,
+// microseconds or nanoseconds since the Unix epoch.
+type QueueTime struct {
+	// StartAtProxy starts the server span at the proxy timestamp instead of
+	// when the request was received by the service.
+	StartAtProxy bool
+	// MaxQueueTime discards proxy timestamps resulting in a larger queue time
+	// as clock skew outliers. Proxy timestamps in the future are always
+	// discarded. If 0, DefaultMaxQueueTime is used.
+	MaxQueueTime time.Duration
+}
+
+// ServerQueueTime allows one to record the time requests spent queued in a
+// proxy in front of the service. A "queued" annotation is added at the proxy
+// timestamp and the queue time is tagged as http.queue_time.
+func ServerQueueTime(qt QueueTime) ServerOption {
+	return func(h *handler) {
+		if qt.MaxQueueTime <= 0 {
+			qt.MaxQueueTime = DefaultMaxQueueTime
+		}
+		h.queueTime = &qt
+	}
+}
+
+// applyQueueTime returns the server span options and, if found, the proxy
+// timestamp of the request.
+func (h handler) applyQueueTime(r *http.Request, start time.Time, options ...zipkin.SpanOption) ([]zipkin.SpanOption, time.Time) {
+	if h.queueTime == nil {
+		return options, time.Time{}
+	}
+	requestStart, queued := h.queueTime.requestStart(r, start)
+	if !queued {
+		return options, time.Time{}
+	}
+	if h.queueTime.StartAtProxy {
+		options = append(options, zipkin.StartTime(requestStart))
+	}
+	return options, requestStart
+}
+
+// tagQueueTime records the time the request spent queued in the proxy.
+func tagQueueTime(sp zipkin.Span, requestStart, start time.Time) {
+	sp.Annotate(requestStart, "queued")
+	sp.Tag(TagHTTPQueueTime, start.Sub(requestStart).String())
+}
+
+// requestStart returns the proxy timestamp of the request if found and within
+// the configured bounds.
+func (q QueueTime) requestStart(r *http.Request, now time.Time) (time.Time, bool) {
+	for _, header := range []string{"X-Request-Start", "X-Queue-Start"} {
+		if value := r.Header.Get(header); value != "" {
+			ts, ok := parseRequestStart(value)
+			if !ok {
+				continue
+			}
+			if queued := now.Sub(ts); queued < 0 || queued > q.MaxQueueTime {
+				return time.Time{}, false
+			}
+			return ts, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// parseRequestStart parses a proxy timestamp, detecting its unit based on its
+// magnitude.
+func parseRequestStart(value string) (time.Time, bool) {
+	value = strings.TrimPrefix(strings.TrimSpace(value), "t=")
+	ts, err := strconv.ParseFloat(value, 64)
+	if err != nil || ts <= 0 {
+		return time.Time{}, false
+	}
+	switch {
+	case ts > 1e18:
+		return time.Unix(0, int64(ts)), true
+	case ts > 1e15:
+		return time.Unix(0, int64(ts*1e3)), true
+	case ts > 1e12:
+		return time.Unix(0, int64(ts*1e6)), true
+	default:
+		return time.Unix(0, int64(ts*1e9)), true
+	}
+}
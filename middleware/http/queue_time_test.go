@@ -0,0 +1,105 @@
+// Copyright 2022 The OpenZipkin Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http_test
+
+import (
+	"bytes"
+	"fmt"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/openzipkin/zipkin-go"
+	mw "github.com/openzipkin/zipkin-go/middleware/http"
+	"github.com/openzipkin/zipkin-go/reporter/recorder"
+)
+
+func TestHTTPServerQueueTime(t *testing.T) {
+	proxyTime := time.Now().Add(-50 * time.Millisecond)
+
+	testCases := []struct {
+		header string
+		value  string
+	}{
+		{"X-Request-Start", fmt.Sprintf("t=%d", proxyTime.UnixNano()/1e3)},
+		{"X-Request-Start", fmt.Sprintf("%d", proxyTime.UnixNano()/1e6)},
+		{"X-Queue-Start", fmt.Sprintf("t=%.3f", float64(proxyTime.UnixNano())/1e9)},
+		{"X-Queue-Start", fmt.Sprintf("%d", proxyTime.UnixNano())},
+	}
+
+	for _, startAtProxy := range []bool{false, true} {
+		for _, tc := range testCases {
+			var (
+				spanRecorder = &recorder.ReporterRecorder{}
+				tr, _        = zipkin.NewTracer(spanRecorder)
+				handler      = mw.NewServerMiddleware(tr, mw.ServerQueueTime(mw.QueueTime{
+					StartAtProxy: startAtProxy,
+				}))(httpHandler(200, nil, &bytes.Buffer{}))
+				r = httptest.NewRequest("GET", "/", nil)
+			)
+			r.Header.Set(tc.header, tc.value)
+			handler.ServeHTTP(httptest.NewRecorder(), r)
+
+			spans := spanRecorder.Flush()
+			if want, have := 1, len(spans); want != have {
+				t.Fatalf("unexpected number of spans, want %d, have %d", want, have)
+			}
+			span := spans[0]
+			if len(span.Annotations) == 0 || span.Annotations[0].Value != "queued" {
+				t.Errorf("[%s: %s] expected queued annotation, have %+v", tc.header, tc.value, span.Annotations)
+				continue
+			}
+			if diff := span.Annotations[0].Timestamp.Sub(proxyTime); diff < -time.Millisecond || diff > time.Millisecond {
+				t.Errorf("[%s: %s] unexpected queued timestamp, off by %s", tc.header, tc.value, diff)
+			}
+			queueTime, err := time.ParseDuration(span.Tags[mw.TagHTTPQueueTime])
+			if err != nil || queueTime < 49*time.Millisecond {
+				t.Errorf("[%s: %s] unexpected queue time tag: %s", tc.header, tc.value, span.Tags[mw.TagHTTPQueueTime])
+			}
+			if want, have := startAtProxy, span.Timestamp.Equal(span.Annotations[0].Timestamp); want != have {
+				t.Errorf("[%s: %s] unexpected span start %s", tc.header, tc.value, span.Timestamp)
+			}
+		}
+	}
+}
+
+func TestHTTPServerQueueTimeSkew(t *testing.T) {
+	for _, proxyTime := range []time.Time{
+		time.Now().Add(time.Hour),
+		time.Now().Add(-time.Hour),
+	} {
+		var (
+			spanRecorder = &recorder.ReporterRecorder{}
+			tr, _        = zipkin.NewTracer(spanRecorder)
+			handler      = mw.NewServerMiddleware(tr, mw.ServerQueueTime(mw.QueueTime{
+				StartAtProxy: true,
+			}))(httpHandler(200, nil, &bytes.Buffer{}))
+			r = httptest.NewRequest("GET", "/", nil)
+		)
+		r.Header.Set("X-Request-Start", fmt.Sprintf("t=%d", proxyTime.UnixNano()/1e3))
+		handler.ServeHTTP(httptest.NewRecorder(), r)
+
+		spans := spanRecorder.Flush()
+		if want, have := 1, len(spans); want != have {
+			t.Fatalf("unexpected number of spans, want %d, have %d", want, have)
+		}
+		if _, ok := spans[0].Tags[mw.TagHTTPQueueTime]; ok {
+			t.Errorf("expected skewed proxy timestamp to be discarded")
+		}
+		if time.Since(spans[0].Timestamp) > time.Minute {
+			t.Errorf("expected span to start at local time, have %s", spans[0].Timestamp)
+		}
+	}
+}
@@ -0,0 +1,79 @@
+// Copyright 2022 The OpenZipkin Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/openzipkin/zipkin-go"
+)
+
+// W3C Trace Context response header
+const TraceResponseHeader = "traceresponse"
+
+// ServerTimingHeader holds the Server-Timing response header name.
+const ServerTimingHeader = "Server-Timing"
+
+// ResponseHeaders configures the trace identifiers exposed to HTTP clients
+// through response headers. Headers are also written for unsampled requests.
+type ResponseHeaders struct {
+	// TraceIDHeader holds the name of the response header to hold the trace
+	// ID, e.g. X-Trace-Id. If empty the header is not written.
+	TraceIDHeader string
+	// TraceResponse enables the W3C traceresponse header.
+	TraceResponse bool
+	// ServerTiming enables a Server-Timing entry holding the time spent in
+	// the server span until the response headers were written.
+	ServerTiming bool
+}
+
+// ServerResponseHeaders allows one to expose the trace identifiers of server
+// spans to clients through response headers.
+func ServerResponseHeaders(rh ResponseHeaders) ServerOption {
+	return func(h *handler) {
+		h.responseHeaders = &rh
+	}
+}
+
+// responseHeaderWriter returns the function writing the configured response
+// headers, or nil if response headers are not enabled.
+func (h handler) responseHeaderWriter(sp zipkin.Span, start time.Time) func(http.Header) {
+	if h.responseHeaders == nil {
+		return nil
+	}
+	rh := *h.responseHeaders
+	return func(header http.Header) {
+		sc := sp.Context()
+		if rh.TraceIDHeader != "" {
+			header.Set(rh.TraceIDHeader, sc.TraceID.String())
+		}
+		if rh.TraceResponse {
+			var flags byte
+			if sc.Debug || (sc.Sampled != nil && *sc.Sampled) {
+				flags = 1
+			}
+			header.Set(TraceResponseHeader, fmt.Sprintf(
+				"00-%016x%016x-%016x-%02x", sc.TraceID.High, sc.TraceID.Low, uint64(sc.ID), flags,
+			))
+		}
+		if rh.ServerTiming {
+			dur := float64(time.Since(start).Microseconds()) / 1000
+			header.Add(ServerTimingHeader, "app;dur="+strconv.FormatFloat(dur, 'f', -1, 64))
+		}
+	}
+}
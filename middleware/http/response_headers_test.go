@@ -0,0 +1,74 @@
+// Copyright 2022 The OpenZipkin Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http_test
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/openzipkin/zipkin-go"
+	mw "github.com/openzipkin/zipkin-go/middleware/http"
+	"github.com/openzipkin/zipkin-go/reporter/recorder"
+)
+
+func TestHTTPServerResponseHeaders(t *testing.T) {
+	handlers := map[string]http.Handler{
+		"write":     httpHandler(200, nil, bytes.NewBufferString("body")),
+		"no output": http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}),
+	}
+
+	for _, sampler := range []zipkin.Sampler{zipkin.AlwaysSample, zipkin.NeverSample} {
+		for name, next := range handlers {
+			var (
+				spanRecorder = &recorder.ReporterRecorder{}
+				tr, _        = zipkin.NewTracer(spanRecorder, zipkin.WithSampler(sampler))
+				handler      = mw.NewServerMiddleware(tr, mw.ServerResponseHeaders(mw.ResponseHeaders{
+					TraceIDHeader: "X-Trace-Id",
+					TraceResponse: true,
+					ServerTiming:  true,
+				}))(next)
+				w       = httptest.NewRecorder()
+				sampled = sampler(0)
+			)
+
+			handler.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+
+			traceID := w.Header().Get("X-Trace-Id")
+			if traceID == "" {
+				t.Errorf("[%s, sampled=%t] expected trace id header", name, sampled)
+				continue
+			}
+			flags := "00"
+			if sampled {
+				flags = "01"
+				spans := spanRecorder.Flush()
+				if len(spans) != 1 || spans[0].TraceID.String() != traceID {
+					t.Errorf("[%s] trace id header doesn't match span: %s", name, traceID)
+				}
+			}
+			traceResponse := w.Header().Get(mw.TraceResponseHeader)
+			if want := "00-" + strings.Repeat("0", 32-len(traceID)) + traceID + "-"; !strings.HasPrefix(traceResponse, want) ||
+				!strings.HasSuffix(traceResponse, "-"+flags) {
+				t.Errorf("[%s, sampled=%t] unexpected traceresponse header: %s", name, sampled, traceResponse)
+			}
+			if timing := w.Header().Get(mw.ServerTimingHeader); !strings.HasPrefix(timing, "app;dur=") {
+				t.Errorf("[%s, sampled=%t] unexpected Server-Timing header: %s", name, sampled, timing)
+			}
+		}
+	}
+}
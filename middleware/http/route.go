@@ -0,0 +1,87 @@
+// Copyright 2022 The OpenZipkin Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/openzipkin/zipkin-go"
+)
+
+// RouteExtractor returns the low cardinality route template which matched the
+// provided request, e.g. "/users/{id}". If no route is known it should return
+// an empty string. Route templates may be prefixed with a method as used by
+// http.ServeMux patterns, e.g. "GET /users/{id}".
+type RouteExtractor func(r *http.Request) string
+
+// ServerRouteExtractor allows one to set a RouteExtractor used to name server
+// spans after the matched route and tag the http.route. The extractor is
+// called after the wrapped handler has returned so routers which store the
+// matched route on the request can be supported.
+// If omitted, the middleware uses the http.ServeMux pattern found in the
+// request if supported by the Go version in use.
+func ServerRouteExtractor(re RouteExtractor) ServerOption {
+	return func(h *handler) {
+		h.routeExtractor = re
+	}
+}
+
+// ServeMuxRoute returns a RouteExtractor which resolves the pattern of the
+// provided http.ServeMux matching the request.
+func ServeMuxRoute(mux *http.ServeMux) RouteExtractor {
+	return func(r *http.Request) string {
+		_, pattern := mux.Handler(r)
+		return pattern
+	}
+}
+
+// RequestPatternRoute returns the http.ServeMux pattern which matched the
+// request, as found in http.Request.Pattern. On Go versions without
+// http.Request.Pattern it returns an empty string.
+func RequestPatternRoute(r *http.Request) string {
+	return requestPattern(r)
+}
+
+// tagRoute tags the route which matched the request and, unless the span was
+// named otherwise, names the span after it. It returns the found route.
+func (h handler) tagRoute(sp zipkin.Span, req *http.Request, named bool) string {
+	if h.routeExtractor == nil {
+		return ""
+	}
+	name, route := spanNameFromRoute(req.Method, h.routeExtractor(req))
+	if route == "" {
+		return ""
+	}
+	zipkin.TagHTTPRoute.Set(sp, route)
+	if len(h.name) == 0 && !named {
+		sp.SetName(name)
+	}
+	return route
+}
+
+// spanNameFromRoute returns the span name and http.route tag value for the
+// provided request method and route template.
+func spanNameFromRoute(method, route string) (name, httpRoute string) {
+	route = strings.TrimSpace(route)
+	if i := strings.IndexByte(route, ' '); i > 0 {
+		// strip method from http.ServeMux patterns like "GET /users/{id}"
+		route = strings.TrimSpace(route[i+1:])
+	}
+	if route == "" {
+		return method, ""
+	}
+	return strings.ToLower(method) + " " + route, route
+}
@@ -0,0 +1,106 @@
+// Copyright 2022 The OpenZipkin Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build go1.22
+// +build go1.22
+
+// enable http.ServeMux patterns as our go.mod predates Go 1.22
+//go:debug httpmuxgo121=0
+
+package http_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/openzipkin/zipkin-go"
+	mw "github.com/openzipkin/zipkin-go/middleware/http"
+	"github.com/openzipkin/zipkin-go/reporter/recorder"
+)
+
+func TestHTTPServerRequestPattern(t *testing.T) {
+	var (
+		spanRecorder = &recorder.ReporterRecorder{}
+		tr, _        = zipkin.NewTracer(spanRecorder)
+		mux          = http.NewServeMux()
+	)
+
+	mux.HandleFunc("GET /users/{id}", func(http.ResponseWriter, *http.Request) {})
+
+	handler := mw.NewServerMiddleware(tr)(mux)
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/users/123", nil))
+
+	spans := spanRecorder.Flush()
+	if want, have := 1, len(spans); want != have {
+		t.Fatalf("unexpected number of spans, want %d, have %d", want, have)
+	}
+
+	if want, have := "get /users/{id}", spans[0].Name; want != have {
+		t.Errorf("unexpected span name, want %s, have %s", want, have)
+	}
+
+	if want, have := "/users/{id}", spans[0].Tags[string(zipkin.TagHTTPRoute)]; want != have {
+		t.Errorf("unexpected http.route tag, want %s, have %s", want, have)
+	}
+}
+
+func TestHTTPServerRequestPatternCustomParser(t *testing.T) {
+	var (
+		spanRecorder = &recorder.ReporterRecorder{}
+		tr, _        = zipkin.NewTracer(spanRecorder)
+		mux          = http.NewServeMux()
+	)
+
+	mux.HandleFunc("GET /users/{id}", func(http.ResponseWriter, *http.Request) {})
+
+	for _, c := range []struct {
+		name   string
+		parser mw.HTTPRequestParserFunc
+		want   string
+	}{
+		{
+			name: "extra tag",
+			parser: func(r *http.Request, sp zipkin.Span) {
+				mw.DefaultServerRequestParser.ParseRequest(r, sp)
+				sp.Tag("user-agent", r.UserAgent())
+			},
+			want: "get /users/{id}",
+		},
+		{
+			name: "custom name",
+			parser: func(r *http.Request, sp zipkin.Span) {
+				mw.DefaultServerRequestParser.ParseRequest(r, sp)
+				sp.SetName("custom-name")
+			},
+			want: "custom-name",
+		},
+	} {
+		handler := mw.NewServerMiddleware(tr, mw.ServerRequestParser(c.parser))(mux)
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/users/123", nil))
+
+		spans := spanRecorder.Flush()
+		if want, have := 1, len(spans); want != have {
+			t.Fatalf("%s: unexpected number of spans, want %d, have %d", c.name, want, have)
+		}
+
+		if want, have := c.want, spans[0].Name; want != have {
+			t.Errorf("%s: unexpected span name, want %s, have %s", c.name, want, have)
+		}
+
+		if want, have := "/users/{id}", spans[0].Tags[string(zipkin.TagHTTPRoute)]; want != have {
+			t.Errorf("%s: unexpected http.route tag, want %s, have %s", c.name, want, have)
+		}
+	}
+}
@@ -0,0 +1,93 @@
+// Copyright 2022 The OpenZipkin Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/openzipkin/zipkin-go"
+	mw "github.com/openzipkin/zipkin-go/middleware/http"
+	"github.com/openzipkin/zipkin-go/reporter/recorder"
+)
+
+func TestHTTPServerRoute(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/users/", func(http.ResponseWriter, *http.Request) {})
+
+	testCases := []struct {
+		path     string
+		options  []mw.ServerOption
+		wantName string
+		route    string
+	}{
+		{
+			path:     "/users/123",
+			options:  []mw.ServerOption{mw.ServerRouteExtractor(mw.ServeMuxRoute(mux))},
+			wantName: "get /users/",
+			route:    "/users/",
+		},
+		{
+			path: "/users/123",
+			options: []mw.ServerOption{mw.ServerRouteExtractor(func(*http.Request) string {
+				return "GET /users/{id}"
+			})},
+			wantName: "get /users/{id}",
+			route:    "/users/{id}",
+		},
+		{
+			path: "/users/123",
+			options: []mw.ServerOption{
+				mw.SpanName("users"),
+				mw.ServerRouteExtractor(mw.ServeMuxRoute(mux)),
+			},
+			wantName: "users",
+			route:    "/users/",
+		},
+		{
+			path:     "/unknown",
+			options:  []mw.ServerOption{mw.ServerRouteExtractor(mw.ServeMuxRoute(mux))},
+			wantName: "GET",
+		},
+	}
+
+	for _, tc := range testCases {
+		var (
+			spanRecorder = &recorder.ReporterRecorder{}
+			tr, _        = zipkin.NewTracer(spanRecorder)
+			handler      = mw.NewServerMiddleware(tr, tc.options...)(mux)
+		)
+
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", tc.path, nil))
+
+		spans := spanRecorder.Flush()
+		if want, have := 1, len(spans); want != have {
+			t.Fatalf("unexpected number of spans, want %d, have %d", want, have)
+		}
+
+		if want, have := tc.wantName, spans[0].Name; want != have {
+			t.Errorf("unexpected span name, want %s, have %s", want, have)
+		}
+
+		route, found := spans[0].Tags[string(zipkin.TagHTTPRoute)]
+		if tc.route == "" && found {
+			t.Errorf("unexpected http.route tag: %s", route)
+		}
+		if want, have := tc.route, route; want != have {
+			t.Errorf("unexpected http.route tag, want %s, have %s", want, have)
+		}
+	}
+}
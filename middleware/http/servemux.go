@@ -0,0 +1,103 @@
+// Copyright 2022 The OpenZipkin Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http
+
+import (
+	"net/http"
+
+	"github.com/openzipkin/zipkin-go"
+)
+
+// ServeMux wraps http.ServeMux and traces the requests handled by all of its
+// registered handlers. Server spans are named after the matched pattern which
+// is also tagged as http.route.
+type ServeMux struct {
+	mux     *http.ServeMux
+	tracer  *zipkin.Tracer
+	options []ServerOption
+}
+
+// RouteOption allows one to override the instrumentation of a route
+// registered with ServeMux.
+type RouteOption func(*routeConfig)
+
+type routeConfig struct {
+	options  []ServerOption
+	disabled bool
+}
+
+// RouteServerOptions adds ServerOptions for the route, applied after the
+// ServerOptions of the ServeMux.
+func RouteServerOptions(options ...ServerOption) RouteOption {
+	return func(c *routeConfig) {
+		c.options = append(c.options, options...)
+	}
+}
+
+// RouteDisableTracing disables tracing for the route, e.g. for health check
+// endpoints.
+func RouteDisableTracing() RouteOption {
+	return func(c *routeConfig) {
+		c.disabled = true
+	}
+}
+
+// NewServeMux returns a new instrumented ServeMux. The provided ServerOptions
+// are applied to all registered handlers.
+func NewServeMux(tracer *zipkin.Tracer, options ...ServerOption) *ServeMux {
+	return &ServeMux{
+		mux:     http.NewServeMux(),
+		tracer:  tracer,
+		options: options,
+	}
+}
+
+// Handle registers the traced handler for the given pattern.
+func (m *ServeMux) Handle(pattern string, handler http.Handler, options ...RouteOption) {
+	var c routeConfig
+	for _, option := range options {
+		option(&c)
+	}
+	if c.disabled {
+		m.mux.Handle(pattern, handler)
+		return
+	}
+
+	serverOptions := make([]ServerOption, 0, len(m.options)+len(c.options)+1)
+	serverOptions = append(serverOptions, m.options...)
+	serverOptions = append(serverOptions, ServerRouteExtractor(func(*http.Request) string {
+		return pattern
+	}))
+	serverOptions = append(serverOptions, c.options...)
+
+	m.mux.Handle(pattern, NewServerMiddleware(m.tracer, serverOptions...)(handler))
+}
+
+// HandleFunc registers the traced handler function for the given pattern.
+func (m *ServeMux) HandleFunc(pattern string, handler func(http.ResponseWriter, *http.Request), options ...RouteOption) {
+	m.Handle(pattern, http.HandlerFunc(handler), options...)
+}
+
+// Handler returns the handler to use for the given request. See
+// http.ServeMux.Handler.
+func (m *ServeMux) Handler(r *http.Request) (h http.Handler, pattern string) {
+	return m.mux.Handler(r)
+}
+
+// ServeHTTP dispatches the request to the handler whose pattern most closely
+// matches the request URL.
+func (m *ServeMux) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	m.mux.ServeHTTP(w, r)
+}
@@ -0,0 +1,70 @@
+// Copyright 2022 The OpenZipkin Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/openzipkin/zipkin-go"
+	mw "github.com/openzipkin/zipkin-go/middleware/http"
+	"github.com/openzipkin/zipkin-go/reporter/recorder"
+)
+
+func TestServeMux(t *testing.T) {
+	var (
+		spanRecorder = &recorder.ReporterRecorder{}
+		tr, _        = zipkin.NewTracer(spanRecorder)
+		mux          = mw.NewServeMux(tr, mw.ServerTags(map[string]string{"mux": "true"}))
+		noop         = func(http.ResponseWriter, *http.Request) {}
+	)
+
+	mux.HandleFunc("/users/", noop)
+	mux.HandleFunc("/health", noop, mw.RouteDisableTracing())
+	mux.Handle("/static/", http.HandlerFunc(noop), mw.RouteServerOptions(mw.SpanName("static")))
+
+	mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", "/users/123", nil))
+	spans := spanRecorder.Flush()
+	if want, have := 1, len(spans); want != have {
+		t.Fatalf("unexpected number of spans, want %d, have %d", want, have)
+	}
+	if want, have := "post /users/", spans[0].Name; want != have {
+		t.Errorf("unexpected span name, want %s, have %s", want, have)
+	}
+	if want, have := "/users/", spans[0].Tags[string(zipkin.TagHTTPRoute)]; want != have {
+		t.Errorf("unexpected route tag, want %s, have %s", want, have)
+	}
+	if want, have := "true", spans[0].Tags["mux"]; want != have {
+		t.Errorf("expected mux server options to be applied, have %+v", spans[0].Tags)
+	}
+
+	mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/health", nil))
+	if want, have := 0, len(spanRecorder.Flush()); want != have {
+		t.Errorf("expected health route to be untraced, have %d spans", have)
+	}
+
+	mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/static/app.js", nil))
+	spans = spanRecorder.Flush()
+	if want, have := 1, len(spans); want != have {
+		t.Fatalf("unexpected number of spans, want %d, have %d", want, have)
+	}
+	if want, have := "static", spans[0].Name; want != have {
+		t.Errorf("unexpected span name, want %s, have %s", want, have)
+	}
+	if want, have := "/static/", spans[0].Tags[string(zipkin.TagHTTPRoute)]; want != have {
+		t.Errorf("unexpected route tag, want %s, have %s", want, have)
+	}
+}
@@ -15,10 +15,14 @@
 package http
 
 import (
+	"bufio"
 	"io"
+	"net"
 	"net/http"
 	"strconv"
+	"sync"
 	"sync/atomic"
+	"time"
 
 	"github.com/openzipkin/zipkin-go"
 	"github.com/openzipkin/zipkin-go/middleware"
@@ -27,16 +31,35 @@ import (
 )
 
 type handler struct {
-	tracer          *zipkin.Tracer
-	name            string
-	next            http.Handler
-	tagResponseSize bool
-	defaultTags     map[string]string
-	requestSampler  RequestSamplerFunc
-	errHandler      ErrHandler
-	baggage         middleware.BaggageHandler
+	tracer             *zipkin.Tracer
+	name               string
+	next               http.Handler
+	tagResponseSize    bool
+	defaultTags        map[string]string
+	requestSampler     RequestSamplerFunc
+	errHandler         ErrHandler
+	baggage            middleware.BaggageHandler
+	routeExtractor     RouteExtractor
+	requestParser      HTTPRequestParser
+	responseParser     HTTPResponseParser
+	headerCapture      *headerCapture
+	capturePanics      bool
+	panicMode          middleware.PanicMode
+	trustPolicy        TrustPolicy
+	untrustedAction    UntrustedAction
+	debugLimiter       *rateLimiter
+	keepHijacked       bool
+	remoteResolver     middleware.ClientAddressResolver
+	responseHeaders    *ResponseHeaders
+	queueTime          *QueueTime
+	bodyCapture        *bodyCapture
+	protocolTags       bool
+	ignoreCancellation bool
 }
 
+// TagHTTPResponseFlushes holds the number of times the response was flushed.
+const TagHTTPResponseFlushes = "http.response.flushes"
+
 // ServerOption allows Middleware to be optionally configured.
 type ServerOption func(*handler)
 
@@ -57,8 +80,9 @@ func TagResponseSize(enabled bool) ServerOption {
 
 // SpanName sets the name of the spans the middleware creates. Use this if
 // wrapping each endpoint with its own Middleware.
-// If omitting the SpanName option, the middleware will use the http request
-// method as span name.
+// If omitting the SpanName option, the middleware will use the matched route
+// and http request method as span name if a route is known, or the http
+// request method otherwise. See ServerRouteExtractor.
 func SpanName(name string) ServerOption {
 	return func(h *handler) {
 		h.name = name
@@ -89,17 +113,68 @@ func EnableBaggage(b middleware.BaggageHandler) ServerOption {
 	}
 }
 
+// ServerRequestParser allows one to customize the span name and tags based on
+// the incoming request. If omitted, DefaultServerRequestParser is used. Spans
+// named by the parser are not renamed after the matched route.
+func ServerRequestParser(p HTTPRequestParser) ServerOption {
+	return func(h *handler) {
+		h.requestParser = p
+	}
+}
+
+// ServerResponseParser allows one to customize tags and error classification
+// based on the response. If omitted, DefaultResponseParser is used with the
+// ErrHandler set by ServerErrHandler.
+func ServerResponseParser(p HTTPResponseParser) ServerOption {
+	return func(h *handler) {
+		h.responseParser = p
+	}
+}
+
+// ServerCaptureHeaders allows one to capture the allowed request and response
+// headers as tags on server spans.
+func ServerCaptureHeaders(hc HeaderCapture) ServerOption {
+	return func(h *handler) {
+		h.headerCapture = newHeaderCapture(hc)
+	}
+}
+
+// ServerKeepHijackedSpans allows one to keep the server span of hijacked
+// connections, such as websockets, open until the hijacked net.Conn is
+// closed instead of finishing it when the handler returns.
+func ServerKeepHijackedSpans(enable bool) ServerOption {
+	return func(h *handler) {
+		h.keepHijacked = enable
+	}
+}
+
+// ServerRemoteEndpointResolver allows one to resolve the remote endpoint of
+// server spans from forwarding headers set by proxies in front of the
+// service. The proxy address is recorded in the proxy.address tag.
+func ServerRemoteEndpointResolver(resolver middleware.ClientAddressResolver) ServerOption {
+	return func(h *handler) {
+		h.remoteResolver = resolver
+	}
+}
+
 // NewServerMiddleware returns a http.Handler middleware with Zipkin tracing.
 func NewServerMiddleware(t *zipkin.Tracer, options ...ServerOption) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		h := &handler{
-			tracer:     t,
-			next:       next,
-			errHandler: defaultErrHandler,
+			tracer:         t,
+			next:           next,
+			errHandler:     defaultErrHandler,
+			routeExtractor: RequestPatternRoute,
 		}
 		for _, option := range options {
 			option(h)
 		}
+		if h.requestParser == nil {
+			h.requestParser = DefaultServerRequestParser
+		}
+		if h.responseParser == nil {
+			h.responseParser = DefaultResponseParser(h.errHandler)
+		}
 		return h
 	}
 }
@@ -108,14 +183,80 @@ func NewServerMiddleware(t *zipkin.Tracer, options ...ServerOption) func(http.Ha
 func (h handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	var spanName string
 
+	if len(h.name) == 0 {
+		spanName = r.Method
+	} else {
+		spanName = h.name
+	}
+
+	spanContext, foreignContext := h.extractSpanContext(r)
+
+	start := time.Now()
+	spanOptions, requestStart := h.applyQueueTime(r, start,
+		zipkin.Kind(model.Server),
+		zipkin.Parent(spanContext),
+	)
+
+	// create Span using SpanContext if found
+	sp := h.tracer.StartSpan(spanName, spanOptions...)
+	// add our span to context
+	ctx := zipkin.NewContext(r.Context(), sp)
+
+	// link the request to its connection span if traced
+	requestServed(ctx, sp)
+
+	if zipkin.IsNoop(sp) {
+		// While the span is not being recorded, we still want to propagate the context.
+		h.serveNoop(w, r.WithContext(ctx), h.responseHeaderWriter(sp, start))
+		return
+	}
+
+	named := h.tagRequest(r, sp, foreignContext)
+	if !requestStart.IsZero() {
+		tagQueueTime(sp, requestStart, start)
+	}
+
+	// create http.ResponseWriter interceptor for tracking response size and
+	// status code.
+	ri := &rwInterceptor{
+		w:            w,
+		statusCode:   200,
+		sp:           sp,
+		keepHijacked: h.keepHijacked,
+		beforeWrite:  h.responseHeaderWriter(sp, start),
+		bodyCapture:  h.bodyCapture,
+	}
+
+	req, reqBody := h.bodyCapture.captureRequest(sp, r.WithContext(ctx))
+
+	// tag found route, response size and status code on exit
+	defer func() {
+		var recovered interface{}
+		if h.capturePanics {
+			recovered = recover()
+		}
+		h.finishServerSpan(sp, ri, req, reqBody, named, recovered)
+	}()
+
+	// call next http Handler func using our updated context.
+	h.next.ServeHTTP(ri.wrap(), req)
+}
+
+// extractSpanContext returns the SpanContext to use as parent of the server
+// span and, if the incoming trace context was discarded, the foreign
+// SpanContext.
+func (h handler) extractSpanContext(r *http.Request) (model.SpanContext, *model.SpanContext) {
 	// try to extract B3 Headers from upstream
 	spanContext := h.tracer.Extract(b3.ExtractHTTP(r))
 
+	// only trusted requests can dictate our trace context
+	spanContext, foreignContext := h.applyTrustPolicy(r, spanContext)
+
 	// store registered headers to be propagated in spanContext
 	if h.baggage != nil {
 		spanContext.Baggage = h.baggage.New()
 		for key, values := range r.Header {
-			spanContext.Baggage.Add(key, values...)
+			middleware.ExtractBaggage(spanContext.Baggage, key, values...)
 		}
 	}
 
@@ -125,71 +266,139 @@ func (h handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	if len(h.name) == 0 {
-		spanName = r.Method
-	} else {
-		spanName = h.name
-	}
-
-	// create Span using SpanContext if found
-	sp := h.tracer.StartSpan(
-		spanName,
-		zipkin.Kind(model.Server),
-		zipkin.Parent(spanContext),
-	)
-	// add our span to context
-	ctx := zipkin.NewContext(r.Context(), sp)
+	return spanContext, foreignContext
+}
 
-	if zipkin.IsNoop(sp) {
-		// While the span is not being recorded, we still want to propagate the context.
-		h.next.ServeHTTP(w, r.WithContext(ctx))
-		return
+// serveNoop serves requests for which the span is not recorded. The
+// beforeWrite function, if any, is called before the response headers are
+// written.
+func (h handler) serveNoop(w http.ResponseWriter, r *http.Request, beforeWrite func(http.Header)) {
+	recoverPanics := h.capturePanics && h.panicMode == middleware.PanicRecover
+	if recoverPanics || beforeWrite != nil {
+		ri := &rwInterceptor{w: w, statusCode: 200, beforeWrite: beforeWrite}
+		// make sure headers are written if the handler doesn't respond
+		defer ri.prepareHeaders()
+		if recoverPanics {
+			defer func() {
+				if recovered := recover(); recovered != nil {
+					if shouldRepanic(h.panicMode, recovered) {
+						panic(recovered)
+					}
+					if !ri.wroteHeader {
+						http.Error(ri, http.StatusText(500), 500)
+					}
+				}
+			}()
+		}
+		w = ri.wrap()
 	}
+	h.next.ServeHTTP(w, r)
+}
 
+// tagRequest tags the request on the server span. It returns true if the
+// request parser named the span.
+func (h handler) tagRequest(r *http.Request, sp zipkin.Span, foreignContext *model.SpanContext) bool {
 	remoteEndpoint, _ := zipkin.NewEndpoint("", r.RemoteAddr)
+	if h.remoteResolver != nil {
+		if ep := h.resolveRemoteEndpoint(r); ep != nil {
+			remoteEndpoint = ep
+			sp.Tag(middleware.TagProxyAddress, r.RemoteAddr)
+		}
+	}
 	sp.SetRemoteEndpoint(remoteEndpoint)
 
 	for k, v := range h.defaultTags {
 		sp.Tag(k, v)
 	}
 
+	tagForeignContext(sp, foreignContext)
+
 	// tag typical HTTP request items
-	zipkin.TagHTTPMethod.Set(sp, r.Method)
-	zipkin.TagHTTPPath.Set(sp, r.URL.Path)
-	if r.ContentLength > 0 {
-		zipkin.TagHTTPRequestSize.Set(sp, strconv.FormatInt(r.ContentLength, 10))
+	parsed := &namedSpan{Span: sp}
+	h.requestParser.ParseRequest(r, parsed)
+	if h.protocolTags {
+		tagProtocol(r, sp)
 	}
+	if h.headerCapture != nil {
+		h.headerCapture.tagRequest(sp, r.Header)
+	}
+	return parsed.named
+}
 
-	// create http.ResponseWriter interceptor for tracking response size and
-	// status code.
-	ri := &rwInterceptor{w: w, statusCode: 200}
-
-	// tag found response size and status code on exit
-	defer func() {
-		code := ri.getStatusCode()
-		sCode := strconv.Itoa(code)
-		if code < 200 || code > 299 {
-			zipkin.TagHTTPStatusCode.Set(sp, sCode)
-			if code > 399 {
-				h.errHandler(sp, nil, code)
-			}
-		}
-		if h.tagResponseSize && atomic.LoadUint64(&ri.size) > 0 {
-			zipkin.TagHTTPResponseSize.Set(sp, ri.getResponseSize())
-		}
-		sp.Finish()
-	}()
+// finishServerSpan tags the found route and response, records the captured
+// bodies and the recovered panic if any and finishes the server span.
+func (h handler) finishServerSpan(sp zipkin.Span, ri *rwInterceptor, req *http.Request, reqBody *captureBuffer, named bool, recovered interface{}) {
+	if recovered != nil {
+		h.recordPanic(sp, ri, recovered)
+	}
+	// make sure headers are written if the handler doesn't respond
+	ri.prepareHeaders()
+	res := HTTPResponse{
+		Request:    req,
+		StatusCode: ri.getStatusCode(),
+		Size:       -1,
+		Route:      h.tagRoute(sp, req, named),
+	}
+	if ri.isHijacked() && !ri.wroteHeader {
+		// the handler took over the connection, e.g. to switch protocols
+		res.StatusCode = http.StatusSwitchingProtocols
+	}
+	if h.tagResponseSize {
+		res.Size = int64(atomic.LoadUint64(&ri.size))
+	}
+	if h.headerCapture != nil {
+		h.headerCapture.tagResponse(sp, ri.Header())
+	}
+	if h.protocolTags {
+		tagResponseEncoding(ri.Header(), sp)
+	}
+	h.bodyCapture.record(sp, TagHTTPRequestBody, reqBody)
+	h.bodyCapture.record(sp, TagHTTPResponseBody, ri.resBody)
+	if flushes := atomic.LoadUint64(&ri.flushes); flushes > 0 {
+		sp.Tag(TagHTTPResponseFlushes, strconv.FormatUint(flushes, 10))
+	}
+	// the first error tag wins, so tag the canonical cancellation first
+	tagCancellation(req.Context(), nil, sp, !h.ignoreCancellation)
+	h.responseParser.ParseResponse(res, sp)
+	ri.finish()
+	if recovered != nil && shouldRepanic(h.panicMode, recovered) {
+		panic(recovered)
+	}
+}
 
-	// call next http Handler func using our updated context.
-	h.next.ServeHTTP(ri.wrap(), r.WithContext(ctx))
+// resolveRemoteEndpoint returns the endpoint of the originating client if it
+// can be resolved from the forwarding headers.
+func (h handler) resolveRemoteEndpoint(r *http.Request) *model.Endpoint {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	ip := h.remoteResolver(net.ParseIP(host), r.Header.Values)
+	if ip == nil {
+		return nil
+	}
+	ep, _ := zipkin.NewEndpoint("", net.JoinHostPort(ip.String(), "0"))
+	return ep
 }
 
 // rwInterceptor intercepts the ResponseWriter, so it can track response size
 // and returned status code.
 type rwInterceptor struct {
-	w          http.ResponseWriter
-	size       uint64
-	statusCode int
+	w            http.ResponseWriter
+	size         uint64
+	flushes      uint64
+	statusCode   int
+	wroteHeader  bool
+	wroteBody    bool
+	sp           zipkin.Span
+	keepHijacked bool
+	hijacked     int32
+	pending      int32
+	beforeWrite  func(http.Header)
+	prepared     bool
+	bodyCapture  *bodyCapture
+	resBody      *captureBuffer
+	bodyDecided  bool
 }
 
 func (r *rwInterceptor) Header() http.Header {
@@ -197,12 +406,111 @@ func (r *rwInterceptor) Header() http.Header {
 }
 
 func (r *rwInterceptor) Write(b []byte) (n int, err error) {
+	r.prepareHeaders()
+	r.wroteHeader = true
+	r.annotateFirstByte()
+	if r.captureBody(b) {
+		_, _ = r.resBody.Write(b)
+	}
 	n, err = r.w.Write(b)
 	atomic.AddUint64(&r.size, uint64(n))
 	return
 }
 
+// ReadFrom is only exposed if the wrapped ResponseWriter implements
+// io.ReaderFrom.
+func (r *rwInterceptor) ReadFrom(src io.Reader) (n int64, err error) {
+	r.prepareHeaders()
+	r.wroteHeader = true
+	r.annotateFirstByte()
+	if r.captureBody(nil) {
+		src = io.TeeReader(src, r.resBody)
+	}
+	n, err = r.w.(io.ReaderFrom).ReadFrom(src)
+	atomic.AddUint64(&r.size, uint64(n))
+	return
+}
+
+// Flush is only exposed if the wrapped ResponseWriter implements
+// http.Flusher.
+func (r *rwInterceptor) Flush() {
+	r.prepareHeaders()
+	r.wroteHeader = true
+	atomic.AddUint64(&r.flushes, 1)
+	r.w.(http.Flusher).Flush()
+}
+
+// Hijack is only exposed if the wrapped ResponseWriter implements
+// http.Hijacker.
+func (r *rwInterceptor) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	conn, rw, err := r.w.(http.Hijacker).Hijack()
+	if err != nil {
+		return conn, rw, err
+	}
+	atomic.StoreInt32(&r.hijacked, 1)
+	if r.keepHijacked && r.sp != nil {
+		// finish the span once both the handler returned and the connection
+		// has been closed.
+		atomic.StoreInt32(&r.pending, 1)
+		conn = &hijackedConn{Conn: conn, onClose: r.finish}
+	}
+	return conn, rw, nil
+}
+
+// prepareHeaders calls beforeWrite once, before the response headers are
+// written.
+func (r *rwInterceptor) prepareHeaders() {
+	if r.prepared || r.wroteHeader || r.isHijacked() {
+		return
+	}
+	r.prepared = true
+	if r.beforeWrite != nil {
+		r.beforeWrite(r.w.Header())
+	}
+}
+
+// captureBody returns true if the response body should be captured. The
+// decision is made on the first write, using the provided data to detect the
+// content type if not set by the handler.
+func (r *rwInterceptor) captureBody(b []byte) bool {
+	if r.bodyCapture == nil || r.sp == nil {
+		return false
+	}
+	if !r.bodyDecided {
+		r.bodyDecided = true
+		contentType := r.w.Header().Get("Content-Type")
+		if contentType == "" && len(b) > 0 {
+			contentType = http.DetectContentType(b)
+		}
+		r.resBody = r.bodyCapture.newBuffer(r.sp, contentType)
+	}
+	return r.resBody != nil
+}
+
+func (r *rwInterceptor) isHijacked() bool {
+	return atomic.LoadInt32(&r.hijacked) == 1
+}
+
+// annotateFirstByte records the wire send annotation when the first response
+// byte is written.
+func (r *rwInterceptor) annotateFirstByte() {
+	if r.wroteBody || r.sp == nil {
+		return
+	}
+	r.wroteBody = true
+	r.sp.Annotate(time.Now(), "ws")
+}
+
+// finish finishes the span unless the hijacked connection is still open.
+func (r *rwInterceptor) finish() {
+	if atomic.AddInt32(&r.pending, -1) < 0 {
+		r.sp.Finish()
+	}
+}
+
 func (r *rwInterceptor) WriteHeader(i int) {
+	r.prepareHeaders()
+	r.wroteHeader = true
 	r.statusCode = i
 	r.w.WriteHeader(i)
 }
@@ -211,10 +519,6 @@ func (r *rwInterceptor) getStatusCode() int {
 	return r.statusCode
 }
 
-func (r *rwInterceptor) getResponseSize() string {
-	return strconv.FormatUint(atomic.LoadUint64(&r.size), 10)
-}
-
 func (r *rwInterceptor) wrap() http.ResponseWriter { // nolint:gocyclo
 	var (
 		hj, i0 = r.w.(http.Hijacker)
@@ -224,6 +528,17 @@ func (r *rwInterceptor) wrap() http.ResponseWriter { // nolint:gocyclo
 		rf, i4 = r.w.(io.ReaderFrom)
 	)
 
+	// intercept the optional interfaces which affect response tracking
+	if i0 {
+		hj = r
+	}
+	if i3 {
+		fl = r
+	}
+	if i4 {
+		rf = r
+	}
+
 	switch {
 	case !i0 && !i1 && !i2 && !i3 && !i4:
 		return struct {
@@ -439,3 +754,16 @@ func (r *rwInterceptor) wrap() http.ResponseWriter { // nolint:gocyclo
 		}{r}
 	}
 }
+
+// hijackedConn calls onClose once the hijacked connection is closed.
+type hijackedConn struct {
+	net.Conn
+	once    sync.Once
+	onClose func()
+}
+
+func (c *hijackedConn) Close() error {
+	err := c.Conn.Close()
+	c.once.Do(c.onClose)
+	return err
+}
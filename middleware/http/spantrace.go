@@ -16,6 +16,7 @@ package http
 
 import (
 	"crypto/tls"
+	"net/http"
 	"net/http/httptrace"
 	"strconv"
 	"strings"
@@ -29,6 +30,34 @@ type spanTrace struct {
 	c *httptrace.ClientTrace
 }
 
+// traceRequest returns the request with a httptrace.ClientTrace recording
+// connection events on the span.
+func traceRequest(req *http.Request, sp zipkin.Span) *http.Request {
+	sptr := spanTrace{
+		Span: sp,
+	}
+	sptr.c = &httptrace.ClientTrace{
+		GetConn:              sptr.getConn,
+		GotConn:              sptr.gotConn,
+		PutIdleConn:          sptr.putIdleConn,
+		GotFirstResponseByte: sptr.gotFirstResponseByte,
+		Got100Continue:       sptr.got100Continue,
+		DNSStart:             sptr.dnsStart,
+		DNSDone:              sptr.dnsDone,
+		ConnectStart:         sptr.connectStart,
+		ConnectDone:          sptr.connectDone,
+		TLSHandshakeStart:    sptr.tlsHandshakeStart,
+		TLSHandshakeDone:     sptr.tlsHandshakeDone,
+		WroteHeaders:         sptr.wroteHeaders,
+		Wait100Continue:      sptr.wait100Continue,
+		WroteRequest:         sptr.wroteRequest,
+	}
+
+	return req.WithContext(
+		httptrace.WithClientTrace(req.Context(), sptr.c),
+	)
+}
+
 func (s *spanTrace) getConn(hostPort string) {
 	s.Annotate(time.Now(), "Connecting")
 	s.Tag("httptrace.get_connection.host_port", hostPort)
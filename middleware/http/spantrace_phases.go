@@ -0,0 +1,236 @@
+// Copyright 2022 The OpenZipkin Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptrace"
+	"strings"
+	"sync"
+
+	zipkin "github.com/openzipkin/zipkin-go"
+	"github.com/openzipkin/zipkin-go/model"
+)
+
+// TraceMode selects how Go's net/http/httptrace events are recorded.
+type TraceMode int
+
+// TraceMode options, which can be combined.
+const (
+	// TraceAnnotations records httptrace events as annotations and tags on
+	// the client span.
+	TraceAnnotations TraceMode = 1 << iota
+	// TraceSpans records the DNS lookup, connect, TLS handshake and time to
+	// first byte phases as child spans of the client span.
+	TraceSpans
+)
+
+// TransportTraceMode allows one to enable Go's net/http/httptrace using the
+// provided TraceMode.
+func TransportTraceMode(mode TraceMode) TransportOption {
+	return func(t *transport) {
+		t.httpTrace = mode != 0
+		t.traceMode = mode
+	}
+}
+
+// ClientTraceMode allows one to enable Go's net/http/httptrace using the
+// provided TraceMode.
+func ClientTraceMode(mode TraceMode) ClientOption {
+	return func(c *Client) {
+		c.httpTrace = mode != 0
+		c.traceMode = mode
+	}
+}
+
+// withHTTPTrace returns the request with httptrace enabled as selected by
+// the TraceMode and, if phases are recorded as child spans, the phaseTrace to
+// end once the round trip completes.
+func (t *transport) withHTTPTrace(req *http.Request, sp zipkin.Span) (*http.Request, *phaseTrace) {
+	var phases *phaseTrace
+	if t.traceMode&TraceSpans != 0 {
+		phases = newPhaseTrace(t.tracer, sp)
+		req = req.WithContext(
+			httptrace.WithClientTrace(req.Context(), phases.clientTrace()),
+		)
+	}
+	if t.traceMode == 0 || t.traceMode&TraceAnnotations != 0 {
+		req = traceRequest(req, sp)
+	}
+	return req, phases
+}
+
+// phaseTrace records httptrace phases as child spans.
+type phaseTrace struct {
+	tracer  *zipkin.Tracer
+	parent  zipkin.Span
+	mtx     sync.Mutex
+	dns     zipkin.Span
+	connect map[string]zipkin.Span
+	remote  *model.Endpoint
+	tls     zipkin.Span
+	ttfb    zipkin.Span
+}
+
+func newPhaseTrace(tracer *zipkin.Tracer, parent zipkin.Span) *phaseTrace {
+	return &phaseTrace{
+		tracer:  tracer,
+		parent:  parent,
+		connect: make(map[string]zipkin.Span),
+	}
+}
+
+func (p *phaseTrace) clientTrace() *httptrace.ClientTrace {
+	return &httptrace.ClientTrace{
+		DNSStart:             p.dnsStart,
+		DNSDone:              p.dnsDone,
+		ConnectStart:         p.connectStart,
+		ConnectDone:          p.connectDone,
+		TLSHandshakeStart:    p.tlsHandshakeStart,
+		TLSHandshakeDone:     p.tlsHandshakeDone,
+		WroteRequest:         p.wroteRequest,
+		GotFirstResponseByte: p.gotFirstResponseByte,
+	}
+}
+
+func (p *phaseTrace) startSpan(name string, options ...zipkin.SpanOption) zipkin.Span {
+	return p.tracer.StartSpan(name, append(options, zipkin.Parent(p.parent.Context()))...)
+}
+
+func (p *phaseTrace) dnsStart(info httptrace.DNSStartInfo) {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+	p.dns = p.startSpan("dns", zipkin.Tags(map[string]string{
+		"dns.host": info.Host,
+	}))
+}
+
+func (p *phaseTrace) dnsDone(info httptrace.DNSDoneInfo) {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+	if p.dns == nil {
+		return
+	}
+	var addrs []string
+	for _, addr := range info.Addrs {
+		addrs = append(addrs, addr.String())
+	}
+	p.dns.Tag("dns.addrs", strings.Join(addrs, ","))
+	if info.Err != nil {
+		zipkin.TagError.Set(p.dns, info.Err.Error())
+	}
+	p.dns.Finish()
+	p.dns = nil
+}
+
+func (p *phaseTrace) connectStart(network, addr string) {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+	options := []zipkin.SpanOption{zipkin.Tags(map[string]string{
+		"net.network": network,
+	})}
+	if ep, err := zipkin.NewEndpoint("", addr); err == nil {
+		options = append(options, zipkin.RemoteEndpoint(ep))
+	}
+	// multiple connection attempts can be in flight (RFC 6555)
+	p.connect[network+" "+addr] = p.startSpan("connect", options...)
+}
+
+func (p *phaseTrace) connectDone(network, addr string, err error) {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+	key := network + " " + addr
+	sp, ok := p.connect[key]
+	if !ok {
+		return
+	}
+	delete(p.connect, key)
+	if err != nil {
+		zipkin.TagError.Set(sp, err.Error())
+	} else if p.remote == nil {
+		// keep the address of the established connection for the handshake
+		p.remote, _ = zipkin.NewEndpoint("", addr)
+	}
+	sp.Finish()
+}
+
+func (p *phaseTrace) tlsHandshakeStart() {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+	var options []zipkin.SpanOption
+	if p.remote != nil {
+		options = append(options, zipkin.RemoteEndpoint(p.remote))
+	}
+	p.tls = p.startSpan("tls handshake", options...)
+}
+
+func (p *phaseTrace) tlsHandshakeDone(state tls.ConnectionState, err error) {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+	if p.tls == nil {
+		return
+	}
+	if state.ServerName != "" {
+		p.tls.Tag("tls.server_name", state.ServerName)
+	}
+	if err != nil {
+		zipkin.TagError.Set(p.tls, err.Error())
+	}
+	p.tls.Finish()
+	p.tls = nil
+}
+
+func (p *phaseTrace) wroteRequest(info httptrace.WroteRequestInfo) {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+	if info.Err != nil {
+		return
+	}
+	p.ttfb = p.startSpan("time to first byte")
+}
+
+func (p *phaseTrace) gotFirstResponseByte() {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+	if p.ttfb == nil {
+		return
+	}
+	p.ttfb.Finish()
+	p.ttfb = nil
+}
+
+// end finishes phase spans left open by an interrupted round trip.
+func (p *phaseTrace) end(err error) {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+	finish := func(sp zipkin.Span) {
+		if sp == nil {
+			return
+		}
+		if err != nil {
+			zipkin.TagError.Set(sp, err.Error())
+		}
+		sp.Finish()
+	}
+	finish(p.dns)
+	finish(p.tls)
+	finish(p.ttfb)
+	for _, sp := range p.connect {
+		finish(sp)
+	}
+	p.dns, p.tls, p.ttfb = nil, nil, nil
+	p.connect = make(map[string]zipkin.Span)
+}
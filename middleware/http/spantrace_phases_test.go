@@ -0,0 +1,82 @@
+// Copyright 2022 The OpenZipkin Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/openzipkin/zipkin-go"
+	mw "github.com/openzipkin/zipkin-go/middleware/http"
+	"github.com/openzipkin/zipkin-go/model"
+	"github.com/openzipkin/zipkin-go/reporter/recorder"
+)
+
+func TestTransportTraceModeSpans(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	for _, mode := range []mw.TraceMode{mw.TraceSpans, mw.TraceSpans | mw.TraceAnnotations} {
+		var (
+			spanRecorder = &recorder.ReporterRecorder{}
+			tr, _        = zipkin.NewTracer(spanRecorder)
+			client, _    = mw.NewClient(
+				tr,
+				mw.WithClient(&http.Client{Transport: srv.Client().Transport.(*http.Transport).Clone()}),
+				mw.ClientTraceMode(mode),
+			)
+		)
+
+		res, err := client.Get(srv.URL)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		_ = res.Body.Close()
+
+		spans := spanRecorder.Flush()
+		var clientSpan *model.SpanModel
+		byName := make(map[string]model.SpanModel)
+		for i, span := range spans {
+			if span.Kind == model.Client {
+				clientSpan = &spans[i]
+			}
+			byName[span.Name] = span
+		}
+
+		if clientSpan == nil {
+			t.Fatalf("expected client span, have %+v", spans)
+		}
+		if want, have := mode&mw.TraceAnnotations != 0, len(clientSpan.Annotations) > 0; want != have {
+			t.Errorf("unexpected client span annotations: %+v", clientSpan.Annotations)
+		}
+
+		for _, name := range []string{"connect", "tls handshake", "time to first byte"} {
+			span, ok := byName[name]
+			if !ok {
+				t.Errorf("expected %s span, have %+v", name, spans)
+				continue
+			}
+			if span.ParentID == nil || *span.ParentID != clientSpan.ID {
+				t.Errorf("expected %s span to be a child of the client span", name)
+			}
+		}
+		for _, name := range []string{"connect", "tls handshake"} {
+			if span := byName[name]; span.RemoteEndpoint == nil || span.RemoteEndpoint.Port == 0 {
+				t.Errorf("expected %s span remote endpoint, have %+v", name, span.RemoteEndpoint)
+			}
+		}
+	}
+}
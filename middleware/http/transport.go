@@ -20,11 +20,12 @@ import (
 	"io/ioutil"
 	"log"
 	"net/http"
-	"net/http/httptrace"
 	"os"
 	"strconv"
+	"time"
 
 	"github.com/openzipkin/zipkin-go"
+	"github.com/openzipkin/zipkin-go/middleware"
 	"github.com/openzipkin/zipkin-go/model"
 	"github.com/openzipkin/zipkin-go/propagation/b3"
 )
@@ -50,15 +51,23 @@ func defaultErrHandler(sp zipkin.Span, err error, statusCode int) {
 type ErrResponseReader func(sp zipkin.Span, body io.Reader)
 
 type transport struct {
-	tracer            *zipkin.Tracer
-	rt                http.RoundTripper
-	httpTrace         bool
-	defaultTags       map[string]string
-	errHandler        ErrHandler
-	errResponseReader *ErrResponseReader
-	logger            *log.Logger
-	requestSampler    RequestSamplerFunc
-	remoteEndpoint    *model.Endpoint
+	tracer             *zipkin.Tracer
+	rt                 http.RoundTripper
+	httpTrace          bool
+	traceMode          TraceMode
+	defaultTags        map[string]string
+	errHandler         ErrHandler
+	errResponseReader  *ErrResponseReader
+	logger             *log.Logger
+	requestSampler     RequestSamplerFunc
+	remoteEndpoint     *model.Endpoint
+	requestParser      HTTPRequestParser
+	responseParser     HTTPResponseParser
+	headerCapture      *headerCapture
+	propagationPolicy  PropagationPolicy
+	bodyTimeout        time.Duration
+	bodyCapture        *bodyCapture
+	ignoreCancellation bool
 }
 
 // TransportOption allows one to configure optional transport configuration.
@@ -126,6 +135,32 @@ func TransportRequestSampler(sampleFunc RequestSamplerFunc) TransportOption {
 	}
 }
 
+// TransportRequestParser allows one to customize the span name and tags based
+// on the outgoing request. If omitted, DefaultClientRequestParser is used.
+func TransportRequestParser(p HTTPRequestParser) TransportOption {
+	return func(t *transport) {
+		t.requestParser = p
+	}
+}
+
+// TransportResponseParser allows one to customize tags and error
+// classification based on the response or round trip error. If omitted,
+// DefaultResponseParser is used with the ErrHandler set by
+// TransportErrHandler.
+func TransportResponseParser(p HTTPResponseParser) TransportOption {
+	return func(t *transport) {
+		t.responseParser = p
+	}
+}
+
+// TransportCaptureHeaders allows one to capture the allowed request and
+// response headers as tags on client spans.
+func TransportCaptureHeaders(hc HeaderCapture) TransportOption {
+	return func(t *transport) {
+		t.headerCapture = newHeaderCapture(hc)
+	}
+}
+
 // NewTransport returns a new Zipkin instrumented http RoundTripper which can be
 // used with a standard library http Client.
 func NewTransport(tracer *zipkin.Tracer, options ...TransportOption) (http.RoundTripper, error) {
@@ -145,18 +180,33 @@ func NewTransport(tracer *zipkin.Tracer, options ...TransportOption) (http.Round
 		option(t)
 	}
 
+	if t.requestParser == nil {
+		t.requestParser = DefaultClientRequestParser
+	}
+	if t.responseParser == nil {
+		t.responseParser = DefaultResponseParser(t.errHandler)
+	}
+
 	return t, nil
 }
 
 // RoundTrip satisfies the RoundTripper interface.
 func (t *transport) RoundTrip(req *http.Request) (res *http.Response, err error) {
 	sp, _ := t.tracer.StartSpanFromContext(
-		req.Context(), req.URL.Scheme+"/"+req.Method, zipkin.Kind(model.Client), zipkin.RemoteEndpoint(t.remoteEndpoint),
+		req.Context(), clientSpanName(req), zipkin.Kind(model.Client), zipkin.RemoteEndpoint(t.remoteEndpoint),
 	)
 
+	// register the attempt if part of a tracked logical request
+	var attempt, redirectStatus int
+	if a := attemptsFromContext(req.Context()); a != nil {
+		attempt, redirectStatus = a.next()
+	}
+
+	propagate := t.propagationPolicy == nil || t.propagationPolicy(req)
+
 	// inject registered headers from span context into the outgoing HTTP request headers
-	if sp.Context().Baggage != nil {
-		sp.Context().Baggage.Iterate(func(key string, values []string) {
+	if propagate && sp.Context().Baggage != nil {
+		middleware.InjectBaggage(sp.Context().Baggage, func(key string, values []string) {
 			for _, val := range values {
 				req.Header.Add(key, val)
 			}
@@ -165,7 +215,9 @@ func (t *transport) RoundTrip(req *http.Request) (res *http.Response, err error)
 
 	if zipkin.IsNoop(sp) {
 		// While the span is not being recorded, we still want to propagate the context.
-		_ = b3.InjectHTTP(req)(sp.Context())
+		if propagate {
+			_ = b3.InjectHTTP(req)(sp.Context())
+		}
 		return t.rt.RoundTrip(req)
 	}
 
@@ -173,35 +225,57 @@ func (t *transport) RoundTrip(req *http.Request) (res *http.Response, err error)
 		sp.Tag(k, v)
 	}
 
+	if attempt > 0 {
+		tagAttempt(sp, req, attempt, redirectStatus)
+	}
+
+	var phases *phaseTrace
 	if t.httpTrace {
-		sptr := spanTrace{
-			Span: sp,
-		}
-		sptr.c = &httptrace.ClientTrace{
-			GetConn:              sptr.getConn,
-			GotConn:              sptr.gotConn,
-			PutIdleConn:          sptr.putIdleConn,
-			GotFirstResponseByte: sptr.gotFirstResponseByte,
-			Got100Continue:       sptr.got100Continue,
-			DNSStart:             sptr.dnsStart,
-			DNSDone:              sptr.dnsDone,
-			ConnectStart:         sptr.connectStart,
-			ConnectDone:          sptr.connectDone,
-			TLSHandshakeStart:    sptr.tlsHandshakeStart,
-			TLSHandshakeDone:     sptr.tlsHandshakeDone,
-			WroteHeaders:         sptr.wroteHeaders,
-			Wait100Continue:      sptr.wait100Continue,
-			WroteRequest:         sptr.wroteRequest,
-		}
+		req, phases = t.withHTTPTrace(req, sp)
+	}
+
+	t.requestParser.ParseRequest(req, sp)
+	if t.headerCapture != nil {
+		t.headerCapture.tagRequest(sp, req.Header)
+	}
+
+	t.injectSpanContext(req, sp, propagate)
 
-		req = req.WithContext(
-			httptrace.WithClientTrace(req.Context(), sptr.c),
-		)
+	req, reqBody := t.bodyCapture.captureRequest(sp, req)
+
+	res, err = t.rt.RoundTrip(req)
+	if phases != nil {
+		phases.end(err)
+	}
+	t.bodyCapture.record(sp, TagHTTPRequestBody, reqBody)
+	if err != nil {
+		t.finishError(req, sp, err)
+		return nil, err
 	}
 
-	zipkin.TagHTTPMethod.Set(sp, req.Method)
-	zipkin.TagHTTPPath.Set(sp, req.URL.Path)
+	t.responseParser.ParseResponse(HTTPResponse{
+		Request:    req,
+		Response:   res,
+		StatusCode: res.StatusCode,
+		Size:       res.ContentLength,
+	}, sp)
+	tagCancellation(req.Context(), nil, sp, !t.ignoreCancellation)
+	if t.headerCapture != nil {
+		t.headerCapture.tagResponse(sp, res.Header)
+	}
 
+	if res.StatusCode > 399 && t.errResponseReader != nil {
+		t.readErrResponse(res, sp)
+	}
+	if !t.finishOnBodyClose(res, sp) {
+		sp.Finish()
+	}
+	return res, err
+}
+
+// injectSpanContext injects the span context, with the sampling decision of
+// the RequestSampler applied, into the request headers if propagated.
+func (t *transport) injectSpanContext(req *http.Request, sp zipkin.Span, propagate bool) {
 	spCtx := sp.Context()
 	if t.requestSampler != nil {
 		if shouldSample := t.requestSampler(req); shouldSample != nil {
@@ -209,36 +283,33 @@ func (t *transport) RoundTrip(req *http.Request) (res *http.Response, err error)
 		}
 	}
 
-	_ = b3.InjectHTTP(req)(spCtx)
+	if t.propagationPolicy != nil {
+		sp.Tag(TagHTTPPropagated, strconv.FormatBool(propagate))
+	}
+	if propagate {
+		_ = b3.InjectHTTP(req)(spCtx)
+	}
+}
 
-	res, err = t.rt.RoundTrip(req)
-	if err != nil {
-		t.errHandler(sp, err, 0)
-		sp.Finish()
-		return nil, err
+// readErrResponse hands a copy of the error response body to the
+// ErrResponseReader.
+func (t *transport) readErrResponse(res *http.Response, sp zipkin.Span) {
+	sBody, err := ioutil.ReadAll(res.Body)
+	if err == nil {
+		res.Body.Close()
+		(*t.errResponseReader)(sp, ioutil.NopCloser(bytes.NewBuffer(sBody)))
+		res.Body = ioutil.NopCloser(bytes.NewBuffer(sBody))
+	} else {
+		t.logger.Printf("failed to read the response body in the ErrResponseReader: %v", err)
 	}
+}
 
-	if res.ContentLength > 0 {
-		zipkin.TagHTTPResponseSize.Set(sp, strconv.FormatInt(res.ContentLength, 10))
-	}
-	if res.StatusCode < 200 || res.StatusCode > 299 {
-		statusCode := strconv.FormatInt(int64(res.StatusCode), 10)
-		zipkin.TagHTTPStatusCode.Set(sp, statusCode)
-		if res.StatusCode > 399 {
-			t.errHandler(sp, nil, res.StatusCode)
-
-			if t.errResponseReader != nil {
-				sBody, err := ioutil.ReadAll(res.Body)
-				if err == nil {
-					res.Body.Close()
-					(*t.errResponseReader)(sp, ioutil.NopCloser(bytes.NewBuffer(sBody)))
-					res.Body = ioutil.NopCloser(bytes.NewBuffer(sBody))
-				} else {
-					t.logger.Printf("failed to read the response body in the ErrResponseReader: %v", err)
-				}
-			}
-		}
+// finishError records the error which prevented a response and finishes the
+// client span.
+func (t *transport) finishError(req *http.Request, sp zipkin.Span, err error) {
+	// the first error tag wins, so tag the canonical cancellation first
+	if cancelled := tagCancellation(req.Context(), err, sp, !t.ignoreCancellation); !cancelled || !t.ignoreCancellation {
+		t.responseParser.ParseResponse(HTTPResponse{Request: req, Err: err}, sp)
 	}
 	sp.Finish()
-	return res, err
 }
@@ -0,0 +1,157 @@
+// Copyright 2022 The OpenZipkin Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http
+
+import (
+	"crypto/subtle"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/openzipkin/zipkin-go"
+	"github.com/openzipkin/zipkin-go/middleware"
+	"github.com/openzipkin/zipkin-go/model"
+)
+
+// Tags used to link the trace context of untrusted requests.
+const (
+	TagUntrustedTraceID = "untrusted.trace_id"
+	TagUntrustedSpanID  = "untrusted.span_id"
+)
+
+// TrustPolicy decides if the trace context headers of an incoming request can
+// be trusted.
+type TrustPolicy func(r *http.Request) bool
+
+// UntrustedAction determines how the server middleware handles the trace
+// context headers of untrusted requests.
+type UntrustedAction int
+
+// UntrustedAction options
+const (
+	// UntrustedRestart ignores the incoming trace context and starts a new
+	// trace. The foreign trace and span ID are tagged on the server span.
+	UntrustedRestart UntrustedAction = iota
+	// UntrustedStripFlags keeps the incoming trace and span IDs but strips the
+	// sampled and debug flags so the local sampler decides.
+	UntrustedStripFlags
+)
+
+// TrustRemoteCIDRs returns a TrustPolicy which trusts requests originating
+// from remote addresses within the provided CIDR ranges.
+func TrustRemoteCIDRs(cidrs ...string) (TrustPolicy, error) {
+	nets, err := middleware.ParseCIDRs(cidrs...)
+	if err != nil {
+		return nil, err
+	}
+	return func(r *http.Request) bool {
+		host, _, err := net.SplitHostPort(r.RemoteAddr)
+		if err != nil {
+			host = r.RemoteAddr
+		}
+		return nets.Contains(net.ParseIP(host))
+	}, nil
+}
+
+// TrustHeader returns a TrustPolicy which trusts requests holding the
+// provided header value. The header must be set, and stripped from external
+// requests, by a gateway in front of the service. As the value acts as a
+// shared secret, requests without the header are never trusted, nor are any
+// requests if the value is empty.
+func TrustHeader(name, value string) TrustPolicy {
+	return func(r *http.Request) bool {
+		have := r.Header.Get(name)
+		if have == "" || value == "" {
+			return false
+		}
+		return subtle.ConstantTimeCompare([]byte(have), []byte(value)) == 1
+	}
+}
+
+// ServerTrustPolicy allows one to define which incoming requests can provide
+// the trace context. The trace context of untrusted requests is handled as
+// defined by the provided UntrustedAction.
+func ServerTrustPolicy(policy TrustPolicy, action UntrustedAction) ServerOption {
+	return func(h *handler) {
+		h.trustPolicy = policy
+		h.untrustedAction = action
+	}
+}
+
+// ServerDebugRateLimit limits the number of incoming requests per second
+// which can force debug tracing. Debug flags exceeding the limit are stripped
+// so the local sampler decides.
+func ServerDebugRateLimit(perSecond int) ServerOption {
+	return func(h *handler) {
+		h.debugLimiter = &rateLimiter{limit: perSecond}
+	}
+}
+
+// applyTrustPolicy returns the SpanContext to use for the request and, if
+// the incoming trace context was discarded, the foreign SpanContext.
+func (h handler) applyTrustPolicy(r *http.Request, sc model.SpanContext) (model.SpanContext, *model.SpanContext) {
+	var foreign *model.SpanContext
+	if h.trustPolicy != nil && !h.trustPolicy(r) {
+		switch h.untrustedAction {
+		case UntrustedStripFlags:
+			sc.Sampled = nil
+			sc.Debug = false
+		default:
+			if !sc.TraceID.Empty() {
+				foreign = &model.SpanContext{TraceID: sc.TraceID, ID: sc.ID}
+			}
+			sc = model.SpanContext{}
+		}
+	}
+	if sc.Debug && h.debugLimiter != nil && !h.debugLimiter.allow(time.Now()) {
+		sc.Debug = false
+	}
+	return sc, foreign
+}
+
+// tagForeignContext tags the identifiers of the discarded trace context, if
+// any, on the server span.
+func tagForeignContext(sp zipkin.Span, foreignContext *model.SpanContext) {
+	if foreignContext == nil {
+		return
+	}
+	sp.Tag(TagUntrustedTraceID, foreignContext.TraceID.String())
+	if foreignContext.ID != 0 {
+		sp.Tag(TagUntrustedSpanID, foreignContext.ID.String())
+	}
+}
+
+// rateLimiter allows up to limit events per second.
+type rateLimiter struct {
+	mtx    sync.Mutex
+	limit  int
+	second int64
+	count  int
+}
+
+func (l *rateLimiter) allow(now time.Time) bool {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+	if second := now.Unix(); second != l.second {
+		l.second = second
+		l.count = 0
+	}
+	if l.count >= l.limit {
+		return false
+	}
+	l.count++
+	return true
+}
@@ -0,0 +1,171 @@
+// Copyright 2022 The OpenZipkin Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http_test
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/openzipkin/zipkin-go"
+	mw "github.com/openzipkin/zipkin-go/middleware/http"
+	"github.com/openzipkin/zipkin-go/propagation/b3"
+	"github.com/openzipkin/zipkin-go/reporter/recorder"
+)
+
+const (
+	foreignTraceID = "1234567890abcdef"
+	foreignSpanID  = "fedcba0987654321"
+)
+
+func newForeignRequest(remoteAddr string) *http.Request {
+	r := httptest.NewRequest("GET", "/", nil)
+	r.RemoteAddr = remoteAddr
+	r.Header.Set(b3.TraceID, foreignTraceID)
+	r.Header.Set(b3.SpanID, foreignSpanID)
+	r.Header.Set(b3.Flags, "1")
+	return r
+}
+
+func TestHTTPServerTrustRemoteCIDRs(t *testing.T) {
+	policy, err := mw.TrustRemoteCIDRs("10.0.0.0/8")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var (
+		spanRecorder = &recorder.ReporterRecorder{}
+		tr, _        = zipkin.NewTracer(spanRecorder, zipkin.WithSampler(zipkin.NeverSample))
+		handler      = mw.NewServerMiddleware(
+			tr, mw.ServerTrustPolicy(policy, mw.UntrustedRestart),
+		)(httpHandler(200, nil, &bytes.Buffer{}))
+	)
+
+	handler.ServeHTTP(httptest.NewRecorder(), newForeignRequest("10.1.2.3:1234"))
+	spans := spanRecorder.Flush()
+	if want, have := 1, len(spans); want != have {
+		t.Fatalf("unexpected number of spans, want %d, have %d", want, have)
+	}
+	if want, have := foreignTraceID, spans[0].TraceID.String(); want != have {
+		t.Errorf("unexpected trace id, want %s, have %s", want, have)
+	}
+
+	handler.ServeHTTP(httptest.NewRecorder(), newForeignRequest("192.168.1.1:1234"))
+	if want, have := 0, len(spanRecorder.Flush()); want != have {
+		t.Errorf("expected untrusted debug flag to be ignored, have %d spans", have)
+	}
+}
+
+func TestHTTPServerUntrustedRestart(t *testing.T) {
+	var (
+		spanRecorder = &recorder.ReporterRecorder{}
+		tr, _        = zipkin.NewTracer(spanRecorder)
+		handler      = mw.NewServerMiddleware(
+			tr, mw.ServerTrustPolicy(mw.TrustHeader("X-Internal", "yes"), mw.UntrustedRestart),
+		)(httpHandler(200, nil, &bytes.Buffer{}))
+	)
+
+	handler.ServeHTTP(httptest.NewRecorder(), newForeignRequest("192.168.1.1:1234"))
+	spans := spanRecorder.Flush()
+	if want, have := 1, len(spans); want != have {
+		t.Fatalf("unexpected number of spans, want %d, have %d", want, have)
+	}
+	if spans[0].TraceID.String() == foreignTraceID || spans[0].ParentID != nil {
+		t.Errorf("expected new trace, have %+v", spans[0].SpanContext)
+	}
+	if want, have := foreignTraceID, spans[0].Tags[mw.TagUntrustedTraceID]; want != have {
+		t.Errorf("unexpected untrusted trace id tag, want %s, have %s", want, have)
+	}
+	if want, have := foreignSpanID, spans[0].Tags[mw.TagUntrustedSpanID]; want != have {
+		t.Errorf("unexpected untrusted span id tag, want %s, have %s", want, have)
+	}
+
+	r := newForeignRequest("192.168.1.1:1234")
+	r.Header.Set("X-Internal", "yes")
+	handler.ServeHTTP(httptest.NewRecorder(), r)
+	spans = spanRecorder.Flush()
+	if want, have := foreignTraceID, spans[0].TraceID.String(); want != have {
+		t.Errorf("unexpected trace id, want %s, have %s", want, have)
+	}
+}
+
+func TestTrustHeader(t *testing.T) {
+	for _, c := range []struct {
+		name   string
+		value  string
+		header string
+		want   bool
+	}{
+		{name: "match", value: "secret", header: "secret", want: true},
+		{name: "mismatch", value: "secret", header: "other"},
+		{name: "missing header", value: "secret"},
+		{name: "empty value", value: ""},
+		{name: "empty value with header", value: "", header: "secret"},
+	} {
+		r := httptest.NewRequest("GET", "/", nil)
+		if c.header != "" {
+			r.Header.Set("X-Internal", c.header)
+		}
+		if want, have := c.want, mw.TrustHeader("X-Internal", c.value)(r); want != have {
+			t.Errorf("%s: unexpected trust decision, want %t, have %t", c.name, want, have)
+		}
+	}
+}
+
+func TestHTTPServerUntrustedStripFlags(t *testing.T) {
+	var (
+		spanRecorder = &recorder.ReporterRecorder{}
+		tr, _        = zipkin.NewTracer(spanRecorder, zipkin.WithSampler(zipkin.AlwaysSample))
+		handler      = mw.NewServerMiddleware(
+			tr, mw.ServerTrustPolicy(func(*http.Request) bool { return false }, mw.UntrustedStripFlags),
+		)(httpHandler(200, nil, &bytes.Buffer{}))
+	)
+
+	handler.ServeHTTP(httptest.NewRecorder(), newForeignRequest("192.168.1.1:1234"))
+	spans := spanRecorder.Flush()
+	if want, have := 1, len(spans); want != have {
+		t.Fatalf("unexpected number of spans, want %d, have %d", want, have)
+	}
+	if want, have := foreignTraceID, spans[0].TraceID.String(); want != have {
+		t.Errorf("unexpected trace id, want %s, have %s", want, have)
+	}
+	if spans[0].Debug {
+		t.Error("expected debug flag to be stripped")
+	}
+}
+
+func TestHTTPServerDebugRateLimit(t *testing.T) {
+	var (
+		spanRecorder = &recorder.ReporterRecorder{}
+		tr, _        = zipkin.NewTracer(spanRecorder, zipkin.WithSampler(zipkin.NeverSample))
+		handler      = mw.NewServerMiddleware(tr, mw.ServerDebugRateLimit(2))(httpHandler(200, nil, &bytes.Buffer{}))
+	)
+
+	for i := 0; i < 5; i++ {
+		handler.ServeHTTP(httptest.NewRecorder(), newForeignRequest("10.1.2.3:1234"))
+	}
+	// requests beyond the limit fall back to the never sampler (unless the
+	// second rolled over during the loop)
+	if have := len(spanRecorder.Flush()); have < 2 || have > 4 {
+		t.Errorf("unexpected number of debug spans, have %d", have)
+	}
+}
+
+func TestTrustRemoteCIDRsInvalid(t *testing.T) {
+	if _, err := mw.TrustRemoteCIDRs("invalid"); err == nil {
+		t.Error("expected error for invalid CIDR")
+	}
+}
@@ -0,0 +1,57 @@
+// Copyright 2022 The OpenZipkin Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package middleware
+
+import (
+	"fmt"
+	"runtime/debug"
+	"time"
+)
+
+// PanicMode determines how server middlewares handle a panic recovered from
+// the wrapped handler after it has been recorded on the server span.
+type PanicMode int
+
+// PanicMode options
+const (
+	// PanicRepanic re-panics with the recovered value after the server span
+	// has been finished.
+	PanicRepanic PanicMode = iota
+	// PanicRecover swallows the panic and responds with an internal server
+	// error.
+	PanicRecover
+)
+
+// MaxPanicStackSize holds the maximum size in bytes of the stack trace
+// recorded on server spans when capturing panics.
+const MaxPanicStackSize = 4096
+
+// PanicSpan holds the span methods used by RecordPanic. It is satisfied by
+// zipkin.Span.
+type PanicSpan interface {
+	Tag(key, value string)
+	Annotate(t time.Time, value string)
+}
+
+// RecordPanic records the recovered panic value as error tag and annotates
+// the stack trace, truncated to MaxPanicStackSize, on the provided span.
+func RecordPanic(span PanicSpan, recovered interface{}) {
+	span.Tag("error", fmt.Sprint(recovered))
+	stack := debug.Stack()
+	if len(stack) > MaxPanicStackSize {
+		stack = stack[:MaxPanicStackSize]
+	}
+	span.Annotate(time.Now(), "panic: "+string(stack))
+}
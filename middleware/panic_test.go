@@ -0,0 +1,52 @@
+// Copyright 2022 The OpenZipkin Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package middleware_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/openzipkin/zipkin-go/middleware"
+)
+
+type panicSpan struct {
+	tags        map[string]string
+	annotations []string
+}
+
+func (s *panicSpan) Tag(key, value string) { s.tags[key] = value }
+
+func (s *panicSpan) Annotate(_ time.Time, value string) {
+	s.annotations = append(s.annotations, value)
+}
+
+func TestRecordPanic(t *testing.T) {
+	span := &panicSpan{tags: make(map[string]string)}
+	middleware.RecordPanic(span, "boom")
+
+	if want, have := "boom", span.tags["error"]; want != have {
+		t.Errorf("unexpected error tag, want %s, have %s", want, have)
+	}
+	if want, have := 1, len(span.annotations); want != have {
+		t.Fatalf("unexpected number of annotations, want %d, have %d", want, have)
+	}
+	if !strings.HasPrefix(span.annotations[0], "panic: goroutine") {
+		t.Errorf("expected stack trace annotation, have %s", span.annotations[0])
+	}
+	if max := len("panic: ") + middleware.MaxPanicStackSize; len(span.annotations[0]) > max {
+		t.Errorf("expected stack trace to be truncated to %d bytes, have %d", max, len(span.annotations[0]))
+	}
+}
@@ -47,6 +47,17 @@ type BaggageFields interface {
 	Iterate(f func(key string, values []string))
 }
 
+// ScopedBaggageFields can be implemented by BaggageFields implementations to
+// scope baggage to a span. When starting a child span the tracer will use
+// Child to obtain the child span's fields so updates made by the child are
+// only visible to the child and its descendants.
+type ScopedBaggageFields interface {
+	// Child returns a copy-on-write view of the fields for use by a child
+	// span. Changes made to either the returned fields or the original fields
+	// afterwards are not visible to the other.
+	Child() BaggageFields
+}
+
 // SpanContext holds the context of a Span.
 type SpanContext struct {
 	TraceID  TraceID       `json:"traceId"`
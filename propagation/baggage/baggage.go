@@ -14,35 +14,116 @@
 
 // Package baggage holds a Baggage propagation implementation based on
 // explicit allowList semantics.
+//
+// Fields registered with New are propagated using their name as header or
+// metadata key. Use NewWithFields to propagate fields using a different remote
+// name, for instance a prefixed one, or to hold fields which are only
+// available in process.
+//
+// Baggage fields are scoped to the span holding them. Child spans receive a
+// copy-on-write view of their parent's fields, so changes made by a child are
+// only visible to the child and its descendants. The fields are safe for
+// concurrent use.
 package baggage
 
 import (
 	"strings"
+	"sync"
 
 	"github.com/openzipkin/zipkin-go/middleware"
 	"github.com/openzipkin/zipkin-go/model"
 )
 
 var (
-	_ middleware.BaggageHandler = (*baggage)(nil)
-	_ model.BaggageFields       = (*baggage)(nil)
+	_ middleware.BaggageHandler      = (*baggage)(nil)
+	_ model.BaggageFields            = (*baggage)(nil)
+	_ middleware.RemoteBaggageFields = (*baggage)(nil)
+	_ model.ScopedBaggageFields      = (*baggage)(nil)
 )
 
+// Field holds the definition of a baggage field.
+type Field struct {
+	name       string
+	remoteName string
+	localOnly  bool
+}
+
+// RemoteField returns a field definition for a field which is propagated
+// using the provided remote name as header or metadata key.
+func RemoteField(name, remoteName string) Field {
+	return Field{
+		name:       strings.ToLower(name),
+		remoteName: strings.ToLower(remoteName),
+	}
+}
+
+// LocalField returns a field definition for a field which is only available
+// in process. It is never injected into, nor extracted from remote requests.
+func LocalField(name string) Field {
+	return Field{
+		name:      strings.ToLower(name),
+		localOnly: true,
+	}
+}
+
+// PrefixedFields returns field definitions for fields which are propagated
+// using their name with the provided prefix as remote name.
+// For example: PrefixedFields("baggage-", "user-id") propagates the user-id
+// field using the baggage-user-id header.
+func PrefixedFields(prefix string, names ...string) []Field {
+	fields := make([]Field, 0, len(names))
+	for _, name := range names {
+		fields = append(fields, RemoteField(name, prefix+name))
+	}
+	return fields
+}
+
 type baggage struct {
 	// registry holds our registry of allowed fields to propagate
-	registry map[string]struct{}
+	registry map[string]Field
+	// remote holds the mapping of remote names to registered fields
+	remote map[string]string
+	// mtx guards fields and shared
+	mtx sync.RWMutex
 	// fields holds the retrieved key-values pairs to propagate
 	fields map[string][]string
+	// shared is true if fields is shared with parent or child spans and needs
+	// to be copied before being mutated
+	shared bool
 }
 
 // New returns a new Baggage interface which is configured to propagate the
 // registered fields.
 func New(keys ...string) middleware.BaggageHandler {
+	fields := make([]Field, 0, len(keys))
+	for _, key := range keys {
+		fields = append(fields, RemoteField(key, key))
+	}
+	return NewWithFields(fields...)
+}
+
+// NewWithFields returns a new Baggage interface which is configured to handle
+// the provided field definitions.
+func NewWithFields(fields ...Field) middleware.BaggageHandler {
 	b := &baggage{
-		registry: make(map[string]struct{}),
+		registry: make(map[string]Field),
+		remote:   make(map[string]string),
 	}
-	for _, key := range keys {
-		b.registry[strings.ToLower(key)] = struct{}{}
+	for _, field := range fields {
+		if field.name == "" {
+			continue
+		}
+		if !field.localOnly && field.remoteName == "" {
+			field.remoteName = field.name
+		}
+		if old, ok := b.registry[field.name]; ok && !old.localOnly {
+			// last definition of a field wins
+			delete(b.remote, old.remoteName)
+		}
+		b.registry[field.name] = field
+		if !field.localOnly {
+			b.remote[field.remoteName] = field.name
+		}
 	}
 	return b
 }
@@ -52,12 +133,53 @@ func New(keys ...string) middleware.BaggageHandler {
 func (b *baggage) New() model.BaggageFields {
 	return &baggage{
 		registry: b.registry,
+		remote:   b.remote,
 		fields:   make(map[string][]string),
 	}
 }
 
+// Child is called by the tracer when starting a child span and returns a
+// copy-on-write view of the current fields.
+func (b *baggage) Child() model.BaggageFields {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+	// from now on our fields map is shared and can no longer be mutated in
+	// place, neither by us nor by the child.
+	b.shared = true
+	return &baggage{
+		registry: b.registry,
+		remote:   b.remote,
+		fields:   b.fields,
+		shared:   true,
+	}
+}
+
+// mutable returns a fields map which can safely be mutated. It must be called
+// while holding the write lock.
+func (b *baggage) mutable() map[string][]string {
+	if b.shared {
+		fields := make(map[string][]string, len(b.fields))
+		for key, v := range b.fields {
+			values := make([]string, len(v))
+			copy(values, v)
+			fields[key] = values
+		}
+		b.fields = fields
+		b.shared = false
+	}
+	return b.fields
+}
+
 func (b *baggage) Get(key string) []string {
-	return b.fields[strings.ToLower(key)]
+	b.mtx.RLock()
+	defer b.mtx.RUnlock()
+	v, ok := b.fields[strings.ToLower(key)]
+	if !ok {
+		return nil
+	}
+	values := make([]string, len(v))
+	copy(values, v)
+	return values
 }
 
 func (b *baggage) Add(key string, values ...string) bool {
@@ -68,8 +190,11 @@ func (b *baggage) Add(key string, values ...string) bool {
 	if _, ok := b.registry[key]; !ok {
 		return false
 	}
+	b.mtx.Lock()
+	fields := b.mutable()
 	// multiple values for a header is allowed
-	b.fields[key] = append(b.fields[key], values...)
+	fields[key] = append(fields[key], values...)
+	b.mtx.Unlock()
 
 	return true
 }
@@ -82,7 +207,11 @@ func (b *baggage) Set(key string, values ...string) bool {
 	if _, ok := b.registry[key]; !ok {
 		return false
 	}
-	b.fields[key] = values
+	v := make([]string, len(values))
+	copy(v, values)
+	b.mtx.Lock()
+	b.mutable()[key] = v
+	b.mtx.Unlock()
 
 	return true
 }
@@ -92,20 +221,50 @@ func (b *baggage) Delete(key string) bool {
 	if _, ok := b.registry[key]; !ok {
 		return false
 	}
-	for k := range b.fields {
-		if key == k {
-			delete(b.fields, k)
-		}
+	b.mtx.Lock()
+	if _, ok := b.fields[key]; ok {
+		delete(b.mutable(), key)
 	}
+	b.mtx.Unlock()
 	return true
 }
 
 func (b *baggage) Iterate(f func(key string, values []string)) {
+	for key, values := range b.snapshot() {
+		f(key, values)
+	}
+}
+
+func (b *baggage) AddRemote(key string, values ...string) bool {
+	name, ok := b.remote[strings.ToLower(key)]
+	if !ok {
+		return false
+	}
+	return b.Add(name, values...)
+}
+
+func (b *baggage) IterateRemote(f func(key string, values []string)) {
+	for key, values := range b.snapshot() {
+		field := b.registry[key]
+		if field.localOnly {
+			continue
+		}
+		f(field.remoteName, values)
+	}
+}
+
+// snapshot returns a copy of the current fields so callbacks can be invoked
+// without holding the lock.
+func (b *baggage) snapshot() map[string][]string {
+	b.mtx.RLock()
+	defer b.mtx.RUnlock()
+	fields := make(map[string][]string, len(b.fields))
 	for key, v := range b.fields {
 		values := make([]string, len(v))
 		copy(values, v)
-		f(key, values)
+		fields[key] = values
 	}
+	return fields
 }
 
 func (b *baggage) IterateKeys(f func(key string)) {
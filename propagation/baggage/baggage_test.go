@@ -15,8 +15,12 @@
 package baggage
 
 import (
+	"strconv"
 	"strings"
+	"sync"
 	"testing"
+
+	"github.com/openzipkin/zipkin-go/model"
 )
 
 func TestBaggageRegistry(t *testing.T) {
@@ -121,3 +125,113 @@ func TestBaggageValues(t *testing.T) {
 	})
 
 }
+
+func TestBaggageFieldDefinitions(t *testing.T) {
+	baggageHandler := NewWithFields(append(
+		PrefixedFields("baggage-", "user-id", "Tenant-Id"),
+		RemoteField("x-request-id", "x-request-id"),
+		LocalField("local-field"),
+	)...)
+
+	baggage := baggageHandler.New().(*baggage)
+
+	if baggage.AddRemote("user-id", "user-id-value") {
+		t.Errorf("expected unprefixed user-id to return false")
+	}
+	if !baggage.AddRemote("Baggage-User-Id", "user-id-value") {
+		t.Errorf("expected Baggage-User-Id to return true")
+	}
+	if !baggage.AddRemote("baggage-tenant-id", "tenant-id-value") {
+		t.Errorf("expected baggage-tenant-id to return true")
+	}
+	if baggage.AddRemote("local-field", "local-value") {
+		t.Errorf("expected local-field to return false on remote extraction")
+	}
+	if !baggage.Add("local-field", "local-value") {
+		t.Errorf("expected local-field to return true on local add")
+	}
+
+	if want, have := "user-id-value", baggage.Get("user-id"); len(have) != 1 || have[0] != want {
+		t.Errorf("expected different user-id value: want %s, have %v", want, have)
+	}
+	if want, have := "tenant-id-value", baggage.Get("tenant-id"); len(have) != 1 || have[0] != want {
+		t.Errorf("expected different tenant-id value: want %s, have %v", want, have)
+	}
+
+	remote := make(map[string][]string)
+	baggage.IterateRemote(func(key string, values []string) {
+		remote[key] = values
+	})
+	if want, have := 2, len(remote); want != have {
+		t.Errorf("unexpected remote field count: want %d, have %d", want, have)
+	}
+	if _, ok := remote["baggage-user-id"]; !ok {
+		t.Errorf("expected remote field baggage-user-id, have %v", remote)
+	}
+	if _, ok := remote["baggage-tenant-id"]; !ok {
+		t.Errorf("expected remote field baggage-tenant-id, have %v", remote)
+	}
+	if _, ok := remote["local-field"]; ok {
+		t.Errorf("expected local-field to not be propagated")
+	}
+}
+
+func TestBaggageCopyOnWrite(t *testing.T) {
+	parent := New("x-request-id", "some-header").New()
+	parent.Set("x-request-id", "parent-value")
+	parent.Add("some-header", "value1")
+
+	child := parent.(model.ScopedBaggageFields).Child()
+
+	// child updates must not be visible to the parent
+	child.Set("x-request-id", "child-value")
+	child.Add("some-header", "value2")
+	if want, have := "parent-value", parent.Get("x-request-id")[0]; want != have {
+		t.Errorf("expected parent value to be unchanged: want %s, have %s", want, have)
+	}
+	if want, have := 1, len(parent.Get("some-header")); want != have {
+		t.Errorf("expected parent value count to be unchanged: want %d, have %d", want, have)
+	}
+
+	// parent updates after creating the child must not be visible to the child
+	parent.Delete("some-header")
+	if want, have := 2, len(child.Get("some-header")); want != have {
+		t.Errorf("expected child value count to be unchanged: want %d, have %d", want, have)
+	}
+
+	// grandchild inherits the child's state
+	grandChild := child.(model.ScopedBaggageFields).Child()
+	if want, have := "child-value", grandChild.Get("x-request-id")[0]; want != have {
+		t.Errorf("expected inherited child value: want %s, have %s", want, have)
+	}
+}
+
+func TestBaggageConcurrentChildren(t *testing.T) {
+	parent := New("x-request-id", "some-header").New()
+	parent.Set("x-request-id", "parent-value")
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			child := parent.(model.ScopedBaggageFields).Child()
+			value := strconv.Itoa(i)
+			child.Set("x-request-id", value)
+			child.Add("some-header", value)
+			parent.Get("x-request-id")
+			parent.Iterate(func(string, []string) {})
+			if have := child.Get("x-request-id"); have[0] != value {
+				t.Errorf("expected child value: want %s, have %s", value, have[0])
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if want, have := "parent-value", parent.Get("x-request-id")[0]; want != have {
+		t.Errorf("expected parent value to be unchanged: want %s, have %s", want, have)
+	}
+	if have := parent.Get("some-header"); have != nil {
+		t.Errorf("expected no parent some-header value, have %v", have)
+	}
+}
@@ -0,0 +1,24 @@
+// Copyright 2022 The OpenZipkin Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+/*
+Package env implements propagation of SpanContext to and from process
+environment variables. This allows child processes started using os/exec to
+continue the trace of the parent process.
+
+The SpanContext can be injected using the B3 single header format (B3
+environment variable) and/or the W3C Trace Context format (TRACEPARENT
+environment variable).
+*/
+package env
@@ -0,0 +1,158 @@
+// Copyright 2022 The OpenZipkin Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package env
+
+import (
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/openzipkin/zipkin-go/model"
+	"github.com/openzipkin/zipkin-go/propagation"
+	"github.com/openzipkin/zipkin-go/propagation/b3"
+)
+
+// InjectOption provides functional option handler type.
+type InjectOption func(opts *InjectOptions)
+
+// InjectOptions provides the available functional options.
+type InjectOptions struct {
+	shouldInjectB3          bool
+	shouldInjectTraceParent bool
+}
+
+// WithB3Only injects the SpanContext in B3 single header format only. This is
+// the default.
+func WithB3Only() InjectOption {
+	return func(opts *InjectOptions) {
+		opts.shouldInjectB3 = true
+		opts.shouldInjectTraceParent = false
+	}
+}
+
+// WithTraceParentOnly injects the SpanContext in W3C Trace Context format
+// only.
+func WithTraceParentOnly() InjectOption {
+	return func(opts *InjectOptions) {
+		opts.shouldInjectB3 = false
+		opts.shouldInjectTraceParent = true
+	}
+}
+
+// WithB3AndTraceParent injects the SpanContext in both B3 single header and
+// W3C Trace Context format.
+func WithB3AndTraceParent() InjectOption {
+	return func(opts *InjectOptions) {
+		opts.shouldInjectB3 = true
+		opts.shouldInjectTraceParent = true
+	}
+}
+
+// Extract will extract a span.Context from the environment of the current
+// process if found. It is typically used at process start.
+func Extract() propagation.Extractor {
+	return ExtractEnv(os.Environ())
+}
+
+// ExtractEnv will extract a span.Context from the provided environment if
+// found. The environment is expected in the "key=value" form as returned by
+// os.Environ. If both B3 and TRACEPARENT are found, B3 takes precedence.
+func ExtractEnv(env []string) propagation.Extractor {
+	return func() (*model.SpanContext, error) {
+		var b3Value, traceParentValue string
+		for _, kv := range env {
+			switch key, value := splitEnv(kv); key {
+			case B3:
+				b3Value = value
+			case TraceParent:
+				traceParentValue = value
+			}
+		}
+
+		var (
+			sc   *model.SpanContext
+			bErr error
+		)
+		if b3Value != "" {
+			sc, bErr = b3.ParseSingleHeader(b3Value)
+			if bErr == nil {
+				return sc, nil
+			}
+		}
+
+		if traceParentValue != "" {
+			return ParseTraceParent(traceParentValue)
+		}
+
+		return nil, bErr
+	}
+}
+
+// InjectCmd will inject a span.Context into the environment of the provided
+// command. If the command has no environment set, it is initialized with the
+// environment of the current process as the command would otherwise inherit
+// it.
+func InjectCmd(cmd *exec.Cmd, opts ...InjectOption) propagation.Injector {
+	return func(sc model.SpanContext) error {
+		if cmd.Env == nil {
+			cmd.Env = os.Environ()
+		}
+		return InjectEnv(&cmd.Env, opts...)(sc)
+	}
+}
+
+// InjectEnv will inject a span.Context into the provided environment. Trace
+// context variables already present, for instance inherited from the current
+// process, are replaced.
+func InjectEnv(env *[]string, opts ...InjectOption) propagation.Injector {
+	options := InjectOptions{shouldInjectB3: true}
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	return func(sc model.SpanContext) error {
+		if (model.SpanContext{}) == sc {
+			return ErrEmptyContext
+		}
+
+		// remove stale trace context so it can't take precedence over ours
+		filtered := make([]string, 0, len(*env)+2)
+		for _, kv := range *env {
+			if key, _ := splitEnv(kv); key != B3 && key != TraceParent {
+				filtered = append(filtered, kv)
+			}
+		}
+
+		if options.shouldInjectB3 {
+			filtered = append(filtered, B3+"="+b3.BuildSingleHeader(sc))
+		}
+
+		if options.shouldInjectTraceParent {
+			if traceParent := BuildTraceParent(sc); traceParent != "" {
+				filtered = append(filtered, TraceParent+"="+traceParent)
+			}
+		}
+
+		*env = filtered
+		return nil
+	}
+}
+
+func splitEnv(kv string) (key, value string) {
+	if i := strings.IndexByte(kv, '='); i >= 0 {
+		return kv[:i], kv[i+1:]
+	}
+	return kv, ""
+}
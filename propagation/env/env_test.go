@@ -0,0 +1,139 @@
+// Copyright 2022 The OpenZipkin Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package env_test
+
+import (
+	"os/exec"
+	"testing"
+
+	"github.com/openzipkin/zipkin-go/model"
+	"github.com/openzipkin/zipkin-go/propagation/env"
+)
+
+func TestTraceParent(t *testing.T) {
+	sc, err := env.ParseTraceParent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	if err != nil {
+		t.Fatalf("ParseTraceParent failed: %+v", err)
+	}
+	if want, have := (model.TraceID{High: 0x4bf92f3577b34da6, Low: 0xa3ce929d0e0e4736}), sc.TraceID; want != have {
+		t.Errorf("TraceID want %+v, have %+v", want, have)
+	}
+	if want, have := model.ID(0x00f067aa0ba902b7), sc.ID; want != have {
+		t.Errorf("ID want %+v, have %+v", want, have)
+	}
+	if sc.Sampled == nil || !*sc.Sampled {
+		t.Errorf("Sampled want true, have %+v", sc.Sampled)
+	}
+
+	sc.TraceID.High = 0
+	if want, have := "00-0000000000000000a3ce929d0e0e4736-00f067aa0ba902b7-01", env.BuildTraceParent(*sc); want != have {
+		t.Errorf("BuildTraceParent want %s, have %s", want, have)
+	}
+
+	for _, invalid := range []string{
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7",
+		"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra",
+	} {
+		if _, err = env.ParseTraceParent(invalid); err != env.ErrInvalidTraceParent {
+			t.Errorf("ParseTraceParent(%q) want %+v, have %+v", invalid, env.ErrInvalidTraceParent, err)
+		}
+	}
+
+	if _, err = env.ParseTraceParent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-0x"); err != env.ErrInvalidTraceParentFlag {
+		t.Errorf("ParseTraceParent want %+v, have %+v", env.ErrInvalidTraceParentFlag, err)
+	}
+}
+
+func TestInjectExtractEnv(t *testing.T) {
+	sampled := true
+	sc := model.SpanContext{
+		TraceID: model.TraceID{High: 1, Low: 2},
+		ID:      model.ID(3),
+		Sampled: &sampled,
+	}
+
+	testCases := []struct {
+		options        []env.InjectOption
+		hasB3          bool
+		hasTraceParent bool
+	}{
+		{nil, true, false},
+		{[]env.InjectOption{env.WithTraceParentOnly()}, false, true},
+		{[]env.InjectOption{env.WithB3AndTraceParent()}, true, true},
+	}
+
+	for _, tc := range testCases {
+		environ := []string{"HOME=/", "B3=stale", "TRACEPARENT=stale"}
+		if err := env.InjectEnv(&environ, tc.options...)(sc); err != nil {
+			t.Fatalf("InjectEnv failed: %+v", err)
+		}
+
+		var hasB3, hasTraceParent bool
+		for _, kv := range environ {
+			switch kv {
+			case "B3=00000000000000010000000000000002-0000000000000003-1":
+				hasB3 = true
+			case "TRACEPARENT=00-00000000000000010000000000000002-0000000000000003-01":
+				hasTraceParent = true
+			case "HOME=/":
+			default:
+				t.Errorf("unexpected environment variable: %s", kv)
+			}
+		}
+		if tc.hasB3 != hasB3 || tc.hasTraceParent != hasTraceParent {
+			t.Errorf("B3 want %t, have %t, TRACEPARENT want %t, have %t",
+				tc.hasB3, hasB3, tc.hasTraceParent, hasTraceParent)
+		}
+
+		have, err := env.ExtractEnv(environ)()
+		if err != nil {
+			t.Fatalf("ExtractEnv failed: %+v", err)
+		}
+		if sc.TraceID != have.TraceID || sc.ID != have.ID || !*have.Sampled {
+			t.Errorf("SpanContext want %+v, have %+v", sc, have)
+		}
+	}
+
+	if have, err := env.ExtractEnv([]string{"HOME=/"})(); have != nil || err != nil {
+		t.Errorf("ExtractEnv want nil, have %+v, %+v", have, err)
+	}
+}
+
+func TestInjectCmd(t *testing.T) {
+	sc := model.SpanContext{
+		TraceID: model.TraceID{Low: 2},
+		ID:      model.ID(3),
+		Debug:   true,
+	}
+
+	cmd := exec.Command("true")
+	if err := env.InjectCmd(cmd)(sc); err != nil {
+		t.Fatalf("InjectCmd failed: %+v", err)
+	}
+	if cmd.Env == nil {
+		t.Fatalf("expected environment to be initialized")
+	}
+
+	have, err := env.ExtractEnv(cmd.Env)()
+	if err != nil {
+		t.Fatalf("ExtractEnv failed: %+v", err)
+	}
+	if sc.TraceID != have.TraceID || sc.ID != have.ID || !have.Debug {
+		t.Errorf("SpanContext want %+v, have %+v", sc, have)
+	}
+}
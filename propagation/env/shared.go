@@ -0,0 +1,30 @@
+// Copyright 2022 The OpenZipkin Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package env
+
+import "errors"
+
+// Common Extraction / Injection errors
+var (
+	ErrEmptyContext           = errors.New("empty request context")
+	ErrInvalidTraceParent     = errors.New("invalid TRACEPARENT value found")
+	ErrInvalidTraceParentFlag = errors.New("invalid TRACEPARENT flags found")
+)
+
+// Default environment variable keys
+const (
+	B3          = "B3"
+	TraceParent = "TRACEPARENT"
+)
@@ -0,0 +1,93 @@
+// Copyright 2022 The OpenZipkin Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package env
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/openzipkin/zipkin-go/model"
+)
+
+// ParseTraceParent takes the value found in the TRACEPARENT environment
+// variable and tries to reconstruct a SpanContext. The value is formatted as
+// {version}-{trace-id}-{parent-id}-{trace-flags}.
+func ParseTraceParent(traceParent string) (*model.SpanContext, error) {
+	if traceParent == "" {
+		return nil, ErrEmptyContext
+	}
+
+	parts := strings.Split(traceParent, "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || parts[0] == "ff" ||
+		(parts[0] == "00" && len(parts) != 4) {
+		return nil, ErrInvalidTraceParent
+	}
+	if _, err := strconv.ParseUint(parts[0], 16, 8); err != nil {
+		return nil, ErrInvalidTraceParent
+	}
+
+	sc := &model.SpanContext{}
+
+	if len(parts[1]) != 32 {
+		return nil, ErrInvalidTraceParent
+	}
+	traceID, err := model.TraceIDFromHex(parts[1])
+	if err != nil || traceID.Empty() {
+		return nil, ErrInvalidTraceParent
+	}
+	sc.TraceID = traceID
+
+	if len(parts[2]) != 16 {
+		return nil, ErrInvalidTraceParent
+	}
+	id, err := strconv.ParseUint(parts[2], 16, 64)
+	if err != nil || id == 0 {
+		return nil, ErrInvalidTraceParent
+	}
+	sc.ID = model.ID(id)
+
+	if len(parts[3]) != 2 {
+		return nil, ErrInvalidTraceParentFlag
+	}
+	flags, err := strconv.ParseUint(parts[3], 16, 8)
+	if err != nil {
+		return nil, ErrInvalidTraceParentFlag
+	}
+	sampled := flags&0x01 == 0x01
+	sc.Sampled = &sampled
+
+	return sc, nil
+}
+
+// BuildTraceParent takes the values from the SpanContext and builds the
+// TRACEPARENT value. As W3C Trace Context has no notion of debug nor deferred
+// sampling decisions, debug is encoded as sampled and a missing decision as
+// not sampled. If the SpanContext holds no trace and span ID an empty string
+// is returned.
+func BuildTraceParent(sc model.SpanContext) string {
+	if sc.TraceID.Empty() || sc.ID == 0 {
+		return ""
+	}
+
+	flags := "00"
+	if sc.Debug || (sc.Sampled != nil && *sc.Sampled) {
+		flags = "01"
+	}
+
+	return fmt.Sprintf(
+		"00-%016x%016x-%s-%s", sc.TraceID.High, sc.TraceID.Low, sc.ID, flags,
+	)
+}
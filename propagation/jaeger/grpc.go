@@ -0,0 +1,49 @@
+// Copyright 2022 The OpenZipkin Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jaeger
+
+import (
+	"google.golang.org/grpc/metadata"
+
+	"github.com/openzipkin/zipkin-go/model"
+	"github.com/openzipkin/zipkin-go/propagation"
+)
+
+// ExtractGRPC will extract a span.Context from the gRPC Request metadata if
+// found in Jaeger header format.
+func ExtractGRPC(md *metadata.MD) propagation.Extractor {
+	return func() (*model.SpanContext, error) {
+		v := (*md)[TraceContextHeader]
+		if len(v) < 1 || v[len(v)-1] == "" {
+			return nil, nil
+		}
+		return ParseHeader(v[len(v)-1])
+	}
+}
+
+// InjectGRPC will inject a span.Context into gRPC metadata.
+func InjectGRPC(md *metadata.MD) propagation.Injector {
+	return func(sc model.SpanContext) error {
+		if (model.SpanContext{}) == sc {
+			return ErrEmptyContext
+		}
+
+		if header := BuildHeader(sc); header != "" {
+			(*md)[TraceContextHeader] = []string{header}
+		}
+
+		return nil
+	}
+}
@@ -0,0 +1,38 @@
+// Copyright 2022 The OpenZipkin Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jaeger
+
+import "errors"
+
+// Common Header Extraction / Injection errors
+var (
+	ErrInvalidHeader            = errors.New("invalid Jaeger header found")
+	ErrInvalidTraceIDValue      = errors.New("invalid Jaeger TraceID value found")
+	ErrInvalidSpanIDValue       = errors.New("invalid Jaeger SpanID value found")
+	ErrInvalidParentSpanIDValue = errors.New("invalid Jaeger ParentSpanID value found")
+	ErrInvalidFlagsValue        = errors.New("invalid Jaeger Flags value found")
+	ErrEmptyContext             = errors.New("empty request context")
+)
+
+// Default Jaeger header key
+const (
+	TraceContextHeader = "uber-trace-id"
+)
+
+// Jaeger flag bits
+const (
+	flagSampled = 0x01
+	flagDebug   = 0x02
+)
@@ -0,0 +1,117 @@
+// Copyright 2022 The OpenZipkin Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jaeger
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/openzipkin/zipkin-go/model"
+)
+
+// ParseHeader takes the value found in the Jaeger uber-trace-id header and
+// tries to reconstruct a SpanContext. The header is formatted as
+// {trace-id}:{span-id}:{parent-span-id}:{flags}.
+func ParseHeader(header string) (*model.SpanContext, error) {
+	if header == "" {
+		return nil, ErrEmptyContext
+	}
+
+	if strings.Contains(header, "%") {
+		// some Jaeger clients URL encode the header value
+		unescaped, err := url.QueryUnescape(header)
+		if err != nil {
+			return nil, ErrInvalidHeader
+		}
+		header = unescaped
+	}
+
+	parts := strings.Split(header, ":")
+	if len(parts) != 4 {
+		return nil, ErrInvalidHeader
+	}
+
+	var (
+		err error
+		id  uint64
+		sc  = &model.SpanContext{}
+	)
+
+	if len(parts[0]) > 32 {
+		return nil, ErrInvalidTraceIDValue
+	}
+	if sc.TraceID, err = model.TraceIDFromHex(parts[0]); err != nil || sc.TraceID.Empty() {
+		return nil, ErrInvalidTraceIDValue
+	}
+
+	if len(parts[1]) > 16 {
+		return nil, ErrInvalidSpanIDValue
+	}
+	if id, err = strconv.ParseUint(parts[1], 16, 64); err != nil || id == 0 {
+		return nil, ErrInvalidSpanIDValue
+	}
+	sc.ID = model.ID(id)
+
+	if len(parts[2]) > 16 {
+		return nil, ErrInvalidParentSpanIDValue
+	}
+	if id, err = strconv.ParseUint(parts[2], 16, 64); err != nil {
+		return nil, ErrInvalidParentSpanIDValue
+	}
+	if id != 0 {
+		parentID := model.ID(id)
+		sc.ParentID = &parentID
+	}
+
+	flags, err := strconv.ParseUint(parts[3], 16, 8)
+	if err != nil {
+		return nil, ErrInvalidFlagsValue
+	}
+	if flags&flagDebug == flagDebug {
+		sc.Debug = true
+	} else {
+		sampled := flags&flagSampled == flagSampled
+		sc.Sampled = &sampled
+	}
+
+	return sc, nil
+}
+
+// BuildHeader takes the values from the SpanContext and builds the Jaeger
+// uber-trace-id header value. Jaeger has no notion of a deferred sampling
+// decision, so a SpanContext without sampling decision is encoded as not
+// sampled. If the SpanContext holds no trace and span ID an empty string is
+// returned.
+func BuildHeader(sc model.SpanContext) string {
+	if sc.TraceID.Empty() || sc.ID == 0 {
+		return ""
+	}
+
+	parentID := "0"
+	if sc.ParentID != nil {
+		parentID = sc.ParentID.String()
+	}
+
+	var flags uint64
+	if sc.Debug {
+		flags = flagDebug | flagSampled
+	} else if sc.Sampled != nil && *sc.Sampled {
+		flags = flagSampled
+	}
+
+	return sc.TraceID.String() + ":" + sc.ID.String() + ":" + parentID + ":" +
+		strconv.FormatUint(flags, 16)
+}
@@ -0,0 +1,154 @@
+// Copyright 2022 The OpenZipkin Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jaeger_test
+
+import (
+	"net/http"
+	"testing"
+
+	"google.golang.org/grpc/metadata"
+
+	"github.com/openzipkin/zipkin-go/model"
+	"github.com/openzipkin/zipkin-go/propagation/jaeger"
+)
+
+func TestParseHeader(t *testing.T) {
+	testCases := []struct {
+		header  string
+		traceID model.TraceID
+		id      model.ID
+		parent  *model.ID
+		debug   bool
+		sampled *bool
+	}{
+		{
+			header:  "463ac35c9f6413ad48485a3953bb6124:a2fb4a1d1a96d312:0:1",
+			traceID: model.TraceID{High: 0x463ac35c9f6413ad, Low: 0x48485a3953bb6124},
+			id:      0xa2fb4a1d1a96d312,
+			sampled: newBool(true),
+		},
+		{
+			header:  "48485a3953bb6124%3Aa2fb4a1d1a96d312%3A1%3A0",
+			traceID: model.TraceID{Low: 0x48485a3953bb6124},
+			id:      0xa2fb4a1d1a96d312,
+			parent:  newID(1),
+			sampled: newBool(false),
+		},
+		{
+			header:  "abc:1:0:3",
+			traceID: model.TraceID{Low: 0xabc},
+			id:      1,
+			debug:   true,
+		},
+	}
+
+	for _, tc := range testCases {
+		sc, err := jaeger.ParseHeader(tc.header)
+		if err != nil {
+			t.Fatalf("ParseHeader(%q) failed: %+v", tc.header, err)
+		}
+		if want, have := tc.traceID, sc.TraceID; want != have {
+			t.Errorf("TraceID want %+v, have %+v", want, have)
+		}
+		if want, have := tc.id, sc.ID; want != have {
+			t.Errorf("ID want %+v, have %+v", want, have)
+		}
+		if (tc.parent == nil) != (sc.ParentID == nil) || (tc.parent != nil && *tc.parent != *sc.ParentID) {
+			t.Errorf("ParentID want %+v, have %+v", tc.parent, sc.ParentID)
+		}
+		if want, have := tc.debug, sc.Debug; want != have {
+			t.Errorf("Debug want %t, have %t", want, have)
+		}
+		if (tc.sampled == nil) != (sc.Sampled == nil) || (tc.sampled != nil && *tc.sampled != *sc.Sampled) {
+			t.Errorf("Sampled want %+v, have %+v", tc.sampled, sc.Sampled)
+		}
+	}
+}
+
+func TestParseHeaderErrors(t *testing.T) {
+	testCases := []struct {
+		header string
+		err    error
+	}{
+		{"", jaeger.ErrEmptyContext},
+		{"abc:1:0", jaeger.ErrInvalidHeader},
+		{"0:1:0:1", jaeger.ErrInvalidTraceIDValue},
+		{"xyz:1:0:1", jaeger.ErrInvalidTraceIDValue},
+		{"abc:0:0:1", jaeger.ErrInvalidSpanIDValue},
+		{"abc:1:xyz:1", jaeger.ErrInvalidParentSpanIDValue},
+		{"abc:1:0:xyz", jaeger.ErrInvalidFlagsValue},
+	}
+
+	for _, tc := range testCases {
+		if _, err := jaeger.ParseHeader(tc.header); err != tc.err {
+			t.Errorf("ParseHeader(%q) want %+v, have %+v", tc.header, tc.err, err)
+		}
+	}
+}
+
+func TestHTTPInjectExtract(t *testing.T) {
+	sc := model.SpanContext{
+		TraceID:  model.TraceID{High: 1, Low: 2},
+		ID:       model.ID(3),
+		ParentID: newID(4),
+		Sampled:  newBool(true),
+	}
+
+	r, _ := http.NewRequest("GET", "http://localhost", nil)
+	if err := jaeger.InjectHTTP(r)(sc); err != nil {
+		t.Fatalf("InjectHTTP failed: %+v", err)
+	}
+
+	if want, have := "00000000000000010000000000000002:0000000000000003:0000000000000004:1", r.Header.Get("Uber-Trace-Id"); want != have {
+		t.Errorf("Header want %s, have %s", want, have)
+	}
+
+	have, err := jaeger.ExtractHTTP(r)()
+	if err != nil {
+		t.Fatalf("ExtractHTTP failed: %+v", err)
+	}
+	if sc.TraceID != have.TraceID || sc.ID != have.ID || *sc.ParentID != *have.ParentID || !*have.Sampled {
+		t.Errorf("SpanContext want %+v, have %+v", sc, have)
+	}
+}
+
+func TestGRPCInjectExtract(t *testing.T) {
+	sc := model.SpanContext{
+		TraceID: model.TraceID{Low: 2},
+		ID:      model.ID(3),
+		Debug:   true,
+	}
+
+	md := metadata.MD{}
+	if err := jaeger.InjectGRPC(&md)(sc); err != nil {
+		t.Fatalf("InjectGRPC failed: %+v", err)
+	}
+
+	have, err := jaeger.ExtractGRPC(&md)()
+	if err != nil {
+		t.Fatalf("ExtractGRPC failed: %+v", err)
+	}
+	if sc.TraceID != have.TraceID || sc.ID != have.ID || !have.Debug {
+		t.Errorf("SpanContext want %+v, have %+v", sc, have)
+	}
+}
+
+func newBool(b bool) *bool {
+	return &b
+}
+
+func newID(id model.ID) *model.ID {
+	return &id
+}
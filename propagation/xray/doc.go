@@ -0,0 +1,29 @@
+// Copyright 2022 The OpenZipkin Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+/*
+Package xray implements serialization and deserialization logic for the AWS
+X-Ray trace header (X-Amzn-Trace-Id).
+
+X-Ray trace IDs consist of a version, a 32 bit epoch in seconds and a 96 bit
+unique identifier. The epoch and unique identifier are mapped to a 128 bit
+Zipkin trace ID without loss of information: the epoch occupies the upper 32
+bits of TraceID.High.
+
+Only trace IDs holding an epoch can be injected, which requires the tracer to
+generate time based 128 bit trace IDs using idgenerator.NewRandomTimestamped.
+For other trace IDs, such as the 64 bit ones generated by default, only the
+sampling decision is propagated.
+*/
+package xray
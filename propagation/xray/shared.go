@@ -0,0 +1,39 @@
+// Copyright 2022 The OpenZipkin Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package xray
+
+import "errors"
+
+// Common Header Extraction / Injection errors
+var (
+	ErrInvalidTraceIDValue = errors.New("invalid X-Ray Root value found")
+	ErrInvalidSpanIDValue  = errors.New("invalid X-Ray Parent value found")
+	ErrInvalidSampledValue = errors.New("invalid X-Ray Sampled value found")
+	ErrInvalidScope        = errors.New("X-Ray Parent requires Root to be available")
+	ErrEmptyContext        = errors.New("empty request context")
+)
+
+// Default X-Ray header key
+const (
+	TraceHeader = "x-amzn-trace-id"
+)
+
+// X-Ray header field keys
+const (
+	rootKey    = "Root"
+	parentKey  = "Parent"
+	sampledKey = "Sampled"
+	version    = "1"
+)
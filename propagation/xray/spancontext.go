@@ -0,0 +1,145 @@
+// Copyright 2022 The OpenZipkin Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package xray
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/openzipkin/zipkin-go/model"
+)
+
+// ParseHeader takes the value found in the X-Ray trace header and tries to
+// reconstruct a SpanContext. Unknown header fields are ignored.
+func ParseHeader(header string) (*model.SpanContext, error) {
+	if header == "" {
+		return nil, ErrEmptyContext
+	}
+
+	var (
+		sc                   = &model.SpanContext{}
+		hasRoot, hasParent   bool
+		root, parent, sample string
+	)
+
+	for _, field := range strings.Split(header, ";") {
+		kv := strings.SplitN(strings.TrimSpace(field), "=", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		switch kv[0] {
+		case rootKey:
+			hasRoot, root = true, kv[1]
+		case parentKey:
+			hasParent, parent = true, kv[1]
+		case sampledKey:
+			sample = kv[1]
+		}
+	}
+
+	if hasRoot {
+		traceID, err := parseRoot(root)
+		if err != nil {
+			return nil, err
+		}
+		sc.TraceID = traceID
+	}
+
+	if hasParent {
+		if !hasRoot {
+			return nil, ErrInvalidScope
+		}
+		if len(parent) != 16 {
+			return nil, ErrInvalidSpanIDValue
+		}
+		id, err := strconv.ParseUint(parent, 16, 64)
+		if err != nil || id == 0 {
+			return nil, ErrInvalidSpanIDValue
+		}
+		sc.ID = model.ID(id)
+	}
+
+	switch sample {
+	case "1":
+		sampled := true
+		sc.Sampled = &sampled
+	case "0":
+		sampled := false
+		sc.Sampled = &sampled
+	case "", "?":
+		// deferred sampling decision
+	default:
+		return nil, ErrInvalidSampledValue
+	}
+
+	return sc, nil
+}
+
+// BuildHeader takes the values from the SpanContext and builds the X-Ray
+// trace header value. Root and Parent are omitted if the trace ID holds no
+// epoch in its upper 32 bits, as X-Ray rejects trace IDs from 1970.
+func BuildHeader(sc model.SpanContext) string {
+	var header []string
+	if sc.TraceID.High>>32 != 0 && sc.ID > 0 {
+		header = append(header,
+			rootKey+"="+buildRoot(sc.TraceID),
+			parentKey+"="+sc.ID.String(),
+		)
+	}
+
+	if sc.Debug {
+		// X-Ray has no notion of debug, which implies sampled.
+		header = append(header, sampledKey+"=1")
+	} else if sc.Sampled != nil {
+		if *sc.Sampled {
+			header = append(header, sampledKey+"=1")
+		} else {
+			header = append(header, sampledKey+"=0")
+		}
+	}
+
+	return strings.Join(header, ";")
+}
+
+// parseRoot parses the X-Ray Root value: version 1, followed by the 32 bit
+// epoch and 96 bit unique identifier in hex, separated by dashes.
+func parseRoot(root string) (model.TraceID, error) {
+	if len(root) != 1+1+8+1+24 || root[0:2] != version+"-" || root[10] != '-' {
+		return model.TraceID{}, ErrInvalidTraceIDValue
+	}
+	high, err := strconv.ParseUint(root[2:10]+root[11:19], 16, 64)
+	if err != nil {
+		return model.TraceID{}, ErrInvalidTraceIDValue
+	}
+	low, err := strconv.ParseUint(root[19:], 16, 64)
+	if err != nil {
+		return model.TraceID{}, ErrInvalidTraceIDValue
+	}
+	traceID := model.TraceID{High: high, Low: low}
+	if traceID.Empty() {
+		return model.TraceID{}, ErrInvalidTraceIDValue
+	}
+	return traceID, nil
+}
+
+// buildRoot builds the X-Ray Root value from a 128 bit trace ID. The upper 32
+// bits of the trace ID hold the epoch.
+func buildRoot(traceID model.TraceID) string {
+	return fmt.Sprintf(
+		"%s-%08x-%08x%016x",
+		version, traceID.High>>32, traceID.High&0xffffffff, traceID.Low,
+	)
+}
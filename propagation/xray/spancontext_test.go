@@ -0,0 +1,162 @@
+// Copyright 2022 The OpenZipkin Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package xray_test
+
+import (
+	"net/http"
+	"testing"
+
+	"google.golang.org/grpc/metadata"
+
+	"github.com/openzipkin/zipkin-go/model"
+	"github.com/openzipkin/zipkin-go/propagation/xray"
+)
+
+const (
+	awsHeader = "Root=1-5759e988-bd862e3fe1be46a994272793;Parent=53995c3f42cd8ad8;Sampled=1"
+)
+
+func TestParseHeader(t *testing.T) {
+	sc, err := xray.ParseHeader(awsHeader)
+	if err != nil {
+		t.Fatalf("ParseHeader failed: %+v", err)
+	}
+
+	if want, have := (model.TraceID{High: 0x5759e988bd862e3f, Low: 0xe1be46a994272793}), sc.TraceID; want != have {
+		t.Errorf("TraceID want %+v, have %+v", want, have)
+	}
+	if want, have := model.ID(0x53995c3f42cd8ad8), sc.ID; want != have {
+		t.Errorf("ID want %+v, have %+v", want, have)
+	}
+	if sc.Sampled == nil || !*sc.Sampled {
+		t.Errorf("Sampled want true, have %+v", sc.Sampled)
+	}
+
+	// round trip
+	if want, have := awsHeader, xray.BuildHeader(*sc); want != have {
+		t.Errorf("BuildHeader want %s, have %s", want, have)
+	}
+}
+
+func TestParseHeaderRootOnly(t *testing.T) {
+	sc, err := xray.ParseHeader("Self=1-67891233-abcdef012345678912345678;Root=1-5759e988-bd862e3fe1be46a994272793;Sampled=?")
+	if err != nil {
+		t.Fatalf("ParseHeader failed: %+v", err)
+	}
+	if sc.TraceID.Empty() {
+		t.Errorf("TraceID want non empty")
+	}
+	if want, have := model.ID(0), sc.ID; want != have {
+		t.Errorf("ID want %+v, have %+v", want, have)
+	}
+	if sc.Sampled != nil {
+		t.Errorf("Sampled want nil, have %+v", *sc.Sampled)
+	}
+}
+
+func TestParseHeaderErrors(t *testing.T) {
+	testCases := []struct {
+		header string
+		err    error
+	}{
+		{"", xray.ErrEmptyContext},
+		{"Root=2-5759e988-bd862e3fe1be46a994272793", xray.ErrInvalidTraceIDValue},
+		{"Root=1-5759e988-bd862e3fe1be46a99427279", xray.ErrInvalidTraceIDValue},
+		{"Root=1-5759e98g-bd862e3fe1be46a994272793", xray.ErrInvalidTraceIDValue},
+		{"Root=1-5759e988-bd862e3fe1be46a994272793;Parent=53995c3f42cd8ad", xray.ErrInvalidSpanIDValue},
+		{"Root=1-5759e988-bd862e3fe1be46a994272793;Sampled=x", xray.ErrInvalidSampledValue},
+		{"Parent=53995c3f42cd8ad8", xray.ErrInvalidScope},
+	}
+
+	for _, tc := range testCases {
+		if _, err := xray.ParseHeader(tc.header); err != tc.err {
+			t.Errorf("ParseHeader(%q) want %+v, have %+v", tc.header, tc.err, err)
+		}
+	}
+}
+
+func TestBuildHeader64BitTraceID(t *testing.T) {
+	sampled := true
+	sc := model.SpanContext{
+		TraceID: model.TraceID{Low: 0xe1be46a994272793},
+		ID:      model.ID(1),
+		Sampled: &sampled,
+	}
+
+	// trace IDs without epoch are rejected by X-Ray, only propagate sampling
+	if want, have := "Sampled=1", xray.BuildHeader(sc); want != have {
+		t.Errorf("BuildHeader want %s, have %s", want, have)
+	}
+
+	sc.Sampled = nil
+	if want, have := "", xray.BuildHeader(sc); want != have {
+		t.Errorf("BuildHeader want empty header, have %s", have)
+	}
+}
+
+func TestHTTPInjectExtract(t *testing.T) {
+	sampled := false
+	sc := model.SpanContext{
+		TraceID: model.TraceID{High: 0x5759e988bd862e3f, Low: 0xe1be46a994272793},
+		ID:      model.ID(1),
+		Sampled: &sampled,
+	}
+
+	r, _ := http.NewRequest("GET", "http://localhost", nil)
+	if err := xray.InjectHTTP(r)(sc); err != nil {
+		t.Fatalf("InjectHTTP failed: %+v", err)
+	}
+
+	if want, have := "Root=1-5759e988-bd862e3fe1be46a994272793;Parent=0000000000000001;Sampled=0", r.Header.Get("X-Amzn-Trace-Id"); want != have {
+		t.Errorf("Header want %s, have %s", want, have)
+	}
+
+	have, err := xray.ExtractHTTP(r)()
+	if err != nil {
+		t.Fatalf("ExtractHTTP failed: %+v", err)
+	}
+	if sc.TraceID != have.TraceID || sc.ID != have.ID || *have.Sampled {
+		t.Errorf("SpanContext want %+v, have %+v", sc, have)
+	}
+
+	if err = xray.InjectHTTP(r)(model.SpanContext{}); err != xray.ErrEmptyContext {
+		t.Errorf("InjectHTTP want %+v, have %+v", xray.ErrEmptyContext, err)
+	}
+}
+
+func TestGRPCInjectExtract(t *testing.T) {
+	sc := model.SpanContext{
+		TraceID: model.TraceID{High: 0x5759e988bd862e3f, Low: 0xe1be46a994272793},
+		ID:      model.ID(1),
+		Debug:   true,
+	}
+
+	md := metadata.MD{}
+	if err := xray.InjectGRPC(&md)(sc); err != nil {
+		t.Fatalf("InjectGRPC failed: %+v", err)
+	}
+
+	have, err := xray.ExtractGRPC(&md)()
+	if err != nil {
+		t.Fatalf("ExtractGRPC failed: %+v", err)
+	}
+	if sc.TraceID != have.TraceID || sc.ID != have.ID || !*have.Sampled {
+		t.Errorf("SpanContext want %+v, have %+v", sc, have)
+	}
+
+	if psc, err := xray.ExtractGRPC(&metadata.MD{})(); psc != nil || err != nil {
+		t.Errorf("ExtractGRPC want nil, have %+v, %+v", psc, err)
+	}
+}
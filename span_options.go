@@ -49,6 +49,10 @@ func Parent(sc model.SpanContext) SpanOption {
 			return
 		}
 		s.SpanContext = sc
+		if b, ok := sc.Baggage.(model.ScopedBaggageFields); ok {
+			// scope baggage to the span being created
+			s.SpanContext.Baggage = b.Child()
+		}
 	}
 }
 
@@ -16,6 +16,7 @@ package zipkin
 
 import (
 	"context"
+	"strings"
 	"sync/atomic"
 	"time"
 
@@ -29,6 +30,7 @@ import (
 // the NewTracer method.
 type Tracer struct {
 	defaultTags          map[string]string
+	baggageTags          []string
 	extractFailurePolicy ExtractFailurePolicy
 	sampler              Sampler
 	generate             idgenerator.IDGenerator
@@ -120,10 +122,24 @@ func (t *Tracer) StartSpan(name string, options ...SpanOption) Span {
 		option(t, s)
 	}
 
+	// add selected baggage fields as tags
+	if s.Baggage != nil {
+		for _, key := range t.baggageTags {
+			if values := s.Baggage.Get(key); len(values) > 0 {
+				s.Tags[key] = strings.Join(values, ",")
+			}
+		}
+	}
+
 	if s.TraceID.Empty() {
 		// create root span
 		s.SpanContext.TraceID = t.generate.TraceID()
 		s.SpanContext.ID = t.generate.SpanID(s.SpanContext.TraceID)
+	} else if s.SpanContext.ID == 0 {
+		// trace ID only context (e.g. AWS X-Ray Root without Parent), create
+		// root span of the provided trace
+		s.SpanContext.ID = t.generate.SpanID(s.SpanContext.TraceID)
+		s.SpanContext.ParentID = nil
 	} else {
 		// valid parent context found
 		if t.sharedSpans && s.Kind == model.Server {
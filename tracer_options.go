@@ -125,6 +125,16 @@ func WithTags(tags map[string]string) TracerOption {
 	}
 }
 
+// WithBaggageTags allows one to select baggage fields which will be added as
+// tags to each created span holding them. The field name is used as tag key.
+// Multiple values of a field are joined using a comma.
+func WithBaggageTags(keys ...string) TracerOption {
+	return func(o *Tracer) error {
+		o.baggageTags = append(o.baggageTags, keys...)
+		return nil
+	}
+}
+
 // WithNoopTracer allows one to start the Tracer as Noop implementation.
 func WithNoopTracer(tracerNoop bool) TracerOption {
 	return func(o *Tracer) error {
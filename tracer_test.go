@@ -18,11 +18,14 @@ import (
 	"context"
 	"errors"
 	"reflect"
+	"strconv"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/openzipkin/zipkin-go/idgenerator"
 	"github.com/openzipkin/zipkin-go/model"
+	"github.com/openzipkin/zipkin-go/propagation/baggage"
 	"github.com/openzipkin/zipkin-go/reporter"
 )
 
@@ -761,6 +764,100 @@ func TestStartSpanFromContext(t *testing.T) {
 	}
 }
 
+func TestChildSpanBaggageScope(t *testing.T) {
+	rep := reporter.NewNoopReporter()
+	defer rep.Close()
+
+	tr, err := NewTracer(rep)
+	if err != nil {
+		t.Fatalf("unable to create tracer instance: %+v", err)
+	}
+
+	sc := model.SpanContext{Baggage: baggage.New("tenant-id").New()}
+	sc.Baggage.Set("tenant-id", "parent")
+
+	parent := tr.StartSpan("parent", Kind(model.Server), Parent(sc))
+	ctx := NewContext(context.Background(), parent)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			child, childCtx := tr.StartSpanFromContext(ctx, "child")
+			defer child.Finish()
+			value := strconv.Itoa(i)
+			BaggageFromContext(childCtx).Set("tenant-id", value)
+
+			grandChild, _ := tr.StartSpanFromContext(childCtx, "grandchild")
+			defer grandChild.Finish()
+			if have := grandChild.Context().Baggage.Get("tenant-id"); have[0] != value {
+				t.Errorf("grandchild baggage want %s, have %s", value, have[0])
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if want, have := "parent", parent.Context().Baggage.Get("tenant-id")[0]; want != have {
+		t.Errorf("parent baggage want %s, have %s", want, have)
+	}
+}
+
+func TestBaggageTags(t *testing.T) {
+	rep := reporter.NewNoopReporter()
+	defer rep.Close()
+
+	tr, err := NewTracer(rep, WithBaggageTags("tenant-id", "country", "missing"))
+	if err != nil {
+		t.Fatalf("unable to create tracer instance: %+v", err)
+	}
+
+	sc := model.SpanContext{Baggage: baggage.New("tenant-id", "country", "user-id").New()}
+	sc.Baggage.Set("tenant-id", "acme")
+	sc.Baggage.Set("country", "nl", "be")
+	sc.Baggage.Set("user-id", "secret")
+
+	span := tr.StartSpan("test", Kind(model.Server), Parent(sc))
+	span.Finish()
+
+	tags := span.(*spanImpl).Tags
+	if want, have := "acme", tags["tenant-id"]; want != have {
+		t.Errorf("tenant-id tag want %s, have %s", want, have)
+	}
+	if want, have := "nl,be", tags["country"]; want != have {
+		t.Errorf("country tag want %s, have %s", want, have)
+	}
+	if _, found := tags["missing"]; found {
+		t.Errorf("unexpected missing tag")
+	}
+	if _, found := tags["user-id"]; found {
+		t.Errorf("unexpected user-id tag")
+	}
+}
+
+func TestTraceIDOnlyParent(t *testing.T) {
+	rep := reporter.NewNoopReporter()
+	defer rep.Close()
+
+	tr, err := NewTracer(rep)
+	if err != nil {
+		t.Fatalf("unable to create tracer instance: %+v", err)
+	}
+
+	sc := model.SpanContext{TraceID: model.TraceID{High: 1, Low: 2}}
+
+	span := tr.StartSpan("test", Kind(model.Server), Parent(sc))
+	if want, have := sc.TraceID, span.Context().TraceID; want != have {
+		t.Errorf("TraceID want %+v, have %+v", want, have)
+	}
+	if span.Context().ID == 0 {
+		t.Errorf("ID want non zero")
+	}
+	if have := span.Context().ParentID; have != nil {
+		t.Errorf("ParentID want nil, have %+v", *have)
+	}
+}
+
 func TestStartSpanFromContextForEmptyContext(t *testing.T) {
 	ctx := context.Background()
 	rep := reporter.NewNoopReporter()